@@ -0,0 +1,522 @@
+// Package cassette 提供了 shellx 命令执行的录制与回放功能。
+//
+// Cassette 实现了 shellx.Executor 接口：
+//   - 录制模式 (ModeRecord)：通过真实执行器执行命令，并将命令参数、工作目录、
+//     相关环境变量、标准输入摘要以及输出、退出码和耗时记录到磁带中
+//   - 回放模式 (ModeReplay)：从磁带中查找匹配的记录并直接返回，不会创建进程，
+//     未匹配的命令根据配置返回错误或透传给真实执行器
+//
+// 磁带使用 JSON 格式保存，便于审阅和提交到版本库。
+//
+// 基本用法:
+//
+//	c, err := cassette.Open("testdata/git.json", cassette.Options{
+//		Mode:     cassette.ModeReplay,
+//		MatchEnv: []string{"GIT_DIR"},
+//	})
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	prev := shellx.SetDefaultExecutor(c)
+//	defer shellx.SetDefaultExecutor(prev)
+//
+//	out, err := shellx.NewCmd("git", "status").ExecStdout()
+//
+//	// 录制模式下需要调用 Save 写入磁带
+//	err = c.Save()
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gitee.com/MM-Q/shellx"
+)
+
+// ErrNoInteraction 表示回放模式下没有找到匹配的记录
+var ErrNoInteraction = errors.New("cassette: no matching interaction")
+
+// 启动错误的类型
+const (
+	errKindNotFound   = "not_found"  // 命令未找到 (exec.ErrNotFound)
+	errKindPermission = "permission" // 没有权限 (fs.ErrPermission)
+	errKindNotExist   = "not_exist"  // 文件或目录不存在 (fs.ErrNotExist)
+)
+
+// Mode 磁带工作模式
+type Mode int
+
+const (
+	ModeReplay Mode = iota // 回放模式, 从磁带中返回记录的结果
+	ModeRecord             // 录制模式, 执行真实命令并记录结果
+	ModeAuto               // 自动模式, 磁带文件存在时回放, 否则录制
+)
+
+// String 返回模式的字符串表示
+func (m Mode) String() string {
+	switch m {
+	case ModeReplay:
+		return "replay"
+
+	case ModeRecord:
+		return "record"
+
+	case ModeAuto:
+		return "auto"
+
+	default:
+		return "unknown"
+	}
+}
+
+// Options 磁带配置
+type Options struct {
+	Mode        Mode            // 工作模式
+	Inner       shellx.Executor // 真实执行器, 默认为 shellx.OSExecutor
+	MatchEnv    []string        // 参与匹配和记录的环境变量名
+	Passthrough bool            // 回放模式下未匹配的命令是否透传给真实执行器
+}
+
+// Interaction 一次命令执行的记录
+type Interaction struct {
+	Argv         []string          `json:"argv"`                       // 命令名及参数
+	Dir          string            `json:"dir,omitempty"`              // 工作目录
+	Env          map[string]string `json:"env,omitempty"`              // 相关环境变量
+	StdinSHA     string            `json:"stdin_sha256,omitempty"`     // 标准输入的SHA256摘要
+	Stdout       string            `json:"stdout"`                     // 标准输出
+	Stderr       string            `json:"stderr"`                     // 标准错误
+	ExitCode     int               `json:"exit_code"`                  // 退出码
+	StartErr     string            `json:"start_error,omitempty"`      // 启动错误
+	StartErrKind string            `json:"start_error_kind,omitempty"` // 启动错误的类型, 回放时据此还原可判断的错误
+	Error        string            `json:"error,omitempty"`            // 非退出码的等待错误
+	Duration     time.Duration     `json:"duration_ns"`                // 执行耗时
+}
+
+// file 磁带文件格式
+type file struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// formatVersion 磁带文件格式版本
+const formatVersion = 1
+
+// Cassette 录制与回放执行器
+type Cassette struct {
+	path string
+	mode Mode
+	opts Options
+
+	mu           sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// Open 打开磁带
+//
+// 参数:
+//   - path: 磁带文件路径
+//   - opts: 磁带配置
+//
+// 返回:
+//   - *Cassette: 磁带对象
+//   - error: 读取或解析磁带失败时返回错误
+//
+// 注意:
+//   - 回放模式下磁带文件必须存在
+//   - 录制模式下会忽略已有的磁带内容, Save 时整体覆盖
+func Open(path string, opts Options) (*Cassette, error) {
+	if path == "" {
+		return nil, errors.New("cassette path cannot be empty")
+	}
+
+	if opts.Inner == nil {
+		opts.Inner = shellx.OSExecutor{}
+	}
+
+	c := &Cassette{
+		path: path,
+		mode: opts.Mode,
+		opts: opts,
+	}
+
+	// 自动模式根据磁带文件是否存在决定实际模式
+	if c.mode == ModeAuto {
+		if _, err := os.Stat(path); err == nil {
+			c.mode = ModeReplay
+		} else {
+			c.mode = ModeRecord
+		}
+	}
+
+	if c.mode == ModeRecord {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette failed: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse cassette %s failed: %w", path, err)
+	}
+
+	c.interactions = f.Interactions
+	c.used = make([]bool, len(f.Interactions))
+	return c, nil
+}
+
+// Mode 返回磁带实际的工作模式 (自动模式会被解析为录制或回放)
+func (c *Cassette) Mode() Mode {
+	return c.mode
+}
+
+// Interactions 返回磁带中的所有记录
+//
+// 返回:
+//   - []Interaction: 记录的副本
+func (c *Cassette) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return slices.Clone(c.interactions)
+}
+
+// Save 将录制的记录写入磁带文件
+//
+// 返回:
+//   - error: 写入错误
+//
+// 注意:
+//   - 仅在录制模式下写入, 回放模式下调用不做任何操作
+func (c *Cassette) Save() error {
+	if c.mode != ModeRecord {
+		return nil
+	}
+
+	c.mu.Lock()
+	f := file{Version: formatVersion, Interactions: slices.Clone(c.interactions)}
+	c.mu.Unlock()
+
+	if f.Interactions == nil {
+		f.Interactions = []Interaction{}
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode cassette failed: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return fmt.Errorf("create cassette directory failed: %w", err)
+	}
+
+	return os.WriteFile(c.path, append(data, '\n'), 0o644)
+}
+
+// Start 实现 shellx.Executor 接口
+//
+// 参数:
+//   - cmd: 命令对象
+//
+// 返回:
+//   - shellx.Process: 进程句柄
+//   - error: 启动错误
+func (c *Cassette) Start(cmd *shellx.Command) (shellx.Process, error) {
+	key, err := c.keyOf(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.mode == ModeRecord {
+		return c.record(cmd, key)
+	}
+
+	c.mu.Lock()
+	it, ok := c.lookup(key)
+	c.mu.Unlock()
+
+	if !ok {
+		if c.opts.Passthrough {
+			return c.opts.Inner.Start(cmd)
+		}
+		return nil, fmt.Errorf("%w: %s", ErrNoInteraction, cmd.CmdStr())
+	}
+
+	return replay(cmd, it)
+}
+
+// keyOf 提取命令的匹配信息
+//
+// 注意:
+//   - 标准输入会被完整读入内存计算摘要, 并替换为等价的内存读取器
+func (c *Cassette) keyOf(cmd *shellx.Command) (Interaction, error) {
+	key := Interaction{
+		Argv: append([]string{cmd.Name()}, cmd.Args()...),
+		Dir:  cmd.WorkDir(),
+	}
+
+	if len(c.opts.MatchEnv) > 0 {
+		key.Env = make(map[string]string, len(c.opts.MatchEnv))
+		for _, env := range cmd.Env() {
+			k, v, ok := strings.Cut(env, "=")
+			if ok && slices.Contains(c.opts.MatchEnv, k) {
+				key.Env[k] = v // 同名变量后出现的生效
+			}
+		}
+	}
+
+	if r := cmd.Stdin(); r != nil {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return key, fmt.Errorf("read stdin failed: %w", err)
+		}
+		sum := sha256.Sum256(data)
+		key.StdinSHA = hex.EncodeToString(sum[:])
+		cmd.WithStdin(bytes.NewReader(data))
+	}
+
+	return key, nil
+}
+
+// lookup 查找匹配的记录, 优先返回未使用过的记录, 调用方需持有锁
+func (c *Cassette) lookup(key Interaction) (Interaction, bool) {
+	found := -1
+	for i, it := range c.interactions {
+		if !matches(it, key) {
+			continue
+		}
+		if !c.used[i] {
+			c.used[i] = true
+			return it, true
+		}
+		if found < 0 {
+			found = i
+		}
+	}
+
+	// 所有匹配记录都已使用过时重复使用第一条
+	if found >= 0 {
+		return c.interactions[found], true
+	}
+	return Interaction{}, false
+}
+
+// matches 判断记录是否与命令匹配
+func matches(it, key Interaction) bool {
+	if !slices.Equal(it.Argv, key.Argv) || it.Dir != key.Dir || it.StdinSHA != key.StdinSHA {
+		return false
+	}
+	if len(it.Env) != len(key.Env) {
+		return false
+	}
+	for k, v := range key.Env {
+		if rv, ok := it.Env[k]; !ok || rv != v {
+			return false
+		}
+	}
+	return true
+}
+
+// record 执行真实命令并在结束时记录结果
+func (c *Cassette) record(cmd *shellx.Command, key Interaction) (shellx.Process, error) {
+	var stdout, stderr bytes.Buffer
+	userOut, userErr := cmd.Stdout(), cmd.Stderr()
+	if userOut != nil && userOut == userErr {
+		// 原本共用同一个输出时exec只使用一个管道, 拆分后需要加锁避免并发写入
+		shared := &lockedWriter{w: userOut}
+		userOut, userErr = shared, shared
+	}
+	cmd.WithStdout(tee(userOut, &stdout))
+	cmd.WithStderr(tee(userErr, &stderr))
+
+	begin := time.Now()
+	proc, err := c.opts.Inner.Start(cmd)
+	if err != nil {
+		key.ExitCode = -1
+		key.StartErr = err.Error()
+		key.StartErrKind = errorKind(err)
+		c.append(key)
+		return nil, err
+	}
+
+	return &recordProcess{
+		Process: proc,
+		finish: func(err error) {
+			key.Stdout = stdout.String()
+			key.Stderr = stderr.String()
+			key.Duration = time.Since(begin)
+			key.ExitCode, key.Error = outcome(err)
+			c.append(key)
+		},
+	}, nil
+}
+
+// append 追加记录
+func (c *Cassette) append(it Interaction) {
+	c.mu.Lock()
+	c.interactions = append(c.interactions, it)
+	c.used = append(c.used, true)
+	c.mu.Unlock()
+}
+
+// outcome 将等待错误转换为退出码和错误信息
+func outcome(err error) (int, string) {
+	if err == nil {
+		return 0, ""
+	}
+
+	var exitErr interface{ ExitCode() int }
+	if errors.As(err, &exitErr) && exitErr.ExitCode() >= 0 {
+		return exitErr.ExitCode(), ""
+	}
+	return -1, err.Error()
+}
+
+// errorKind 返回启动错误的类型, 无法识别时返回空字符串
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, exec.ErrNotFound):
+		return errKindNotFound
+
+	case errors.Is(err, fs.ErrPermission):
+		return errKindPermission
+
+	case errors.Is(err, fs.ErrNotExist):
+		return errKindNotExist
+
+	default:
+		return ""
+	}
+}
+
+// startError 回放的启动错误, 保留记录的错误信息, 同时可以通过errors.Is判断错误类型
+type startError struct {
+	msg string
+	err error
+}
+
+// Error 返回记录的错误信息
+func (e *startError) Error() string {
+	return e.msg
+}
+
+// Unwrap 返回错误类型对应的底层错误
+func (e *startError) Unwrap() error {
+	return e.err
+}
+
+// replayStartErr 根据记录还原启动错误
+//
+// 返回:
+//   - error: 命令未找到时包装*exec.Error, 其它已知类型包装对应的fs错误, 未知类型只保留错误信息
+func replayStartErr(it Interaction) error {
+	switch it.StartErrKind {
+	case errKindNotFound:
+		return &startError{msg: it.StartErr, err: &exec.Error{Name: it.Argv[0], Err: exec.ErrNotFound}}
+
+	case errKindPermission:
+		return &startError{msg: it.StartErr, err: fs.ErrPermission}
+
+	case errKindNotExist:
+		return &startError{msg: it.StartErr, err: fs.ErrNotExist}
+
+	default:
+		return errors.New(it.StartErr)
+	}
+}
+
+// replay 根据记录构造已结束的进程
+func replay(cmd *shellx.Command, it Interaction) (shellx.Process, error) {
+	if it.StartErr != "" {
+		return nil, replayStartErr(it)
+	}
+
+	writeTo(cmd.Stdout(), it.Stdout)
+	writeTo(cmd.Stderr(), it.Stderr)
+
+	p := &replayProcess{}
+	switch {
+	case it.Error != "":
+		p.err = errors.New(it.Error)
+	case it.ExitCode != 0:
+		p.err = &shellx.ExitError{Code: it.ExitCode}
+	}
+	return p, nil
+}
+
+// recordProcess 录制模式下的进程句柄, 等待结束后记录结果
+type recordProcess struct {
+	shellx.Process
+	finish func(err error)
+}
+
+func (p *recordProcess) Wait() error {
+	err := p.Process.Wait()
+	p.finish(err)
+	return err
+}
+
+// replayProcess 回放模式下的进程句柄, 创建时即已结束
+type replayProcess struct {
+	err error
+}
+
+func (p *replayProcess) Pid() int {
+	return 0
+}
+
+func (p *replayProcess) Wait() error {
+	return p.err
+}
+
+func (p *replayProcess) Signal(os.Signal) error {
+	return os.ErrProcessDone
+}
+
+func (p *replayProcess) Kill() error {
+	return os.ErrProcessDone
+}
+
+// lockedWriter 并发安全的写入器
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// tee 将用户设置的输出与捕获输出合并
+func tee(user, capture io.Writer) io.Writer {
+	if user == nil {
+		return capture
+	}
+	return io.MultiWriter(user, capture)
+}
+
+// writeTo 将内容写入输出, 输出为nil时丢弃
+func writeTo(w io.Writer, s string) {
+	if w == nil || s == "" {
+		return
+	}
+	_, _ = io.WriteString(w, s)
+}
@@ -0,0 +1,155 @@
+package cassette
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gitee.com/MM-Q/shellx"
+)
+
+// TestRecordReplay 测试录制后回放
+func TestRecordReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	rec, err := Open(path, Options{Mode: ModeRecord, MatchEnv: []string{"GREETING"}})
+	if err != nil {
+		t.Fatalf("打开磁带失败: %v", err)
+	}
+
+	out, err := shellx.NewCmd("echo", "hello").WithEnv("GREETING", "hi").WithExecutor(rec).ExecStdout()
+	if err != nil {
+		t.Fatalf("录制执行失败: %v", err)
+	}
+	if strings.TrimSpace(string(out)) != "hello" {
+		t.Fatalf("录制输出不符合预期: %q", out)
+	}
+
+	code := exitCode(t, shellx.NewCmd("sh", "-c", "exit 3").WithShell(shellx.ShellNone).WithExecutor(rec))
+	if code != 3 {
+		t.Fatalf("期望退出码为 3, 实际为 %d", code)
+	}
+
+	if err := rec.Save(); err != nil {
+		t.Fatalf("保存磁带失败: %v", err)
+	}
+
+	play, err := Open(path, Options{Mode: ModeReplay, MatchEnv: []string{"GREETING"}})
+	if err != nil {
+		t.Fatalf("打开磁带失败: %v", err)
+	}
+	if len(play.Interactions()) != 2 {
+		t.Fatalf("期望2条记录, 实际为 %d", len(play.Interactions()))
+	}
+
+	out, err = shellx.NewCmd("echo", "hello").WithEnv("GREETING", "hi").WithExecutor(play).ExecStdout()
+	if err != nil || strings.TrimSpace(string(out)) != "hello" {
+		t.Errorf("回放结果不符合预期, 输出: %q, 错误: %v", out, err)
+	}
+
+	code = exitCode(t, shellx.NewCmd("sh", "-c", "exit 3").WithShell(shellx.ShellNone).WithExecutor(play))
+	if code != 3 {
+		t.Errorf("回放的退出码应为 3, 实际为 %d", code)
+	}
+
+	// 环境变量不同时不匹配
+	err = shellx.NewCmd("echo", "hello").WithEnv("GREETING", "bye").WithExecutor(play).Exec()
+	if err == nil || !strings.Contains(err.Error(), "no matching interaction") {
+		t.Errorf("期望未匹配错误, 实际为: %v", err)
+	}
+}
+
+// TestReplayStdin 测试标准输入摘要参与匹配
+func TestReplayStdin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stdin.json")
+
+	rec, _ := Open(path, Options{Mode: ModeRecord})
+	out, err := shellx.NewCmd("cat").WithStdin(strings.NewReader("abc")).WithExecutor(rec).ExecStdout()
+	if err != nil || string(out) != "abc" {
+		t.Fatalf("录制结果不符合预期, 输出: %q, 错误: %v", out, err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("保存磁带失败: %v", err)
+	}
+
+	play, _ := Open(path, Options{Mode: ModeReplay})
+	out, err = shellx.NewCmd("cat").WithStdin(strings.NewReader("abc")).WithExecutor(play).ExecStdout()
+	if err != nil || string(out) != "abc" {
+		t.Errorf("回放结果不符合预期, 输出: %q, 错误: %v", out, err)
+	}
+
+	_, err = play.Start(shellx.NewCmd("cat").WithStdin(strings.NewReader("xyz")))
+	if !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("标准输入不同时期望 ErrNoInteraction, 实际为: %v", err)
+	}
+}
+
+// TestReplayStartError 测试回放的启动错误可以通过errors.Is判断类型
+func TestReplayStartError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notfound.json")
+	const name = "shellx-cassette-missing-cmd"
+
+	rec, _ := Open(path, Options{Mode: ModeRecord})
+	_, recErr := rec.Start(shellx.NewCmd(name).WithShell(shellx.ShellNone))
+	if !errors.Is(recErr, exec.ErrNotFound) {
+		t.Fatalf("录制时期望命令未找到, 实际为: %v", recErr)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatalf("保存磁带失败: %v", err)
+	}
+
+	play, _ := Open(path, Options{Mode: ModeReplay})
+	_, err := play.Start(shellx.NewCmd(name).WithShell(shellx.ShellNone))
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Errorf("回放时期望 exec.ErrNotFound, 实际为: %v", err)
+	}
+	var execErr *exec.Error
+	if !errors.As(err, &execErr) || execErr.Name != name {
+		t.Errorf("回放时期望 *exec.Error, 实际为: %#v", err)
+	}
+	if err == nil || err.Error() != recErr.Error() {
+		t.Errorf("回放的错误信息应与录制时一致, 期望 %q, 实际为: %v", recErr, err)
+	}
+}
+
+// TestReplayPassthrough 测试未匹配命令透传给真实执行器
+func TestReplayPassthrough(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.json")
+	rec, _ := Open(path, Options{Mode: ModeRecord})
+	if err := rec.Save(); err != nil {
+		t.Fatalf("保存磁带失败: %v", err)
+	}
+
+	play, err := Open(path, Options{Mode: ModeAuto, Passthrough: true})
+	if err != nil {
+		t.Fatalf("打开磁带失败: %v", err)
+	}
+	if play.Mode() != ModeReplay {
+		t.Fatalf("磁带存在时自动模式应解析为回放, 实际为 %s", play.Mode())
+	}
+
+	out, err := shellx.NewCmd("echo", "real").WithExecutor(play).ExecStdout()
+	if err != nil || strings.TrimSpace(string(out)) != "real" {
+		t.Errorf("透传执行结果不符合预期, 输出: %q, 错误: %v", out, err)
+	}
+}
+
+// TestOpenMissing 测试回放模式下磁带不存在
+func TestOpenMissing(t *testing.T) {
+	if _, err := Open(filepath.Join(t.TempDir(), "missing.json"), Options{Mode: ModeReplay}); err == nil {
+		t.Error("回放模式下磁带不存在应返回错误")
+	}
+}
+
+// exitCode 异步执行命令并返回退出码
+func exitCode(t *testing.T, cmd *shellx.Command) int {
+	t.Helper()
+
+	if err := cmd.ExecAsync(); err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+	code, _ := cmd.WaitWithCode()
+	return code
+}
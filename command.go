@@ -8,18 +8,19 @@
 //   - 进程控制：Kill、Signal、IsRunning、GetPID
 //   - 状态管理：IsExecuted（确保命令只执行一次）
 //   - 延迟构建：exec.Cmd 对象在执行时才创建，确保超时控制精确
+//   - 可替换执行器：WithExecutor 指定执行器，便于在测试中替换真实进程
 //
 // 提供完整的命令执行解决方案，支持多种执行模式和丰富的配置选项。
 package shellx
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"sync/atomic"
-	"syscall"
 	"time"
 )
 
@@ -32,10 +33,12 @@ import (
 //   - 属性获取方法不是并发安全的，不要在多个 goroutine 中并发调用
 type Command struct {
 	// 基本命令配置
-	shellType ShellType // shell类型
-	raw       string    // 原始命令字符串
-	name      string    // 命令名
-	args      []string  // 命令参数
+	shellType ShellType       // shell类型
+	shellInv  shellInvocation // shell选项、登录和交互式配置
+	raw       string          // 原始命令字符串
+	name      string          // 命令名
+	args      []string        // 命令参数
+	script    *scriptSource   // 脚本来源 (NewScriptStr/NewScriptFile)
 
 	// 执行环境配置
 	dir    string    // 工作目录
@@ -45,13 +48,42 @@ type Command struct {
 	stderr io.Writer // 标准错误输出
 
 	// 上下文和超时配置
-	userCtx context.Context // 用户设置的上下文
-	timeout time.Duration   // 超时时间
+	userCtx      context.Context // 用户设置的上下文
+	parentCtx    context.Context // 内部绑定的父上下文(如Group的共享上下文)
+	timeout      time.Duration   // 超时时间
+	idleTimeout  time.Duration   // 无输出超时时间
+	killStrategy KillStrategy    // 取消时的终止策略
+	terminator   *treeTerminator // 按进程树终止时的终止器 (命令结束时停止)
+
+	// 信号转发配置
+	forwardSignals []os.Signal // 需要转发给子进程的信号
+	stopForward    func()      // 停止信号转发
+	orphanToken    string      // 孤儿进程标记 (启用收割模式时设置)
+
+	// 执行器配置
+	executor Executor // 命令执行器 (nil表示使用包级默认执行器)
+
+	// 输出文件配置
+	outputFiles []outputFile    // 输出文件配置
+	openFiles   []*rotatingFile // 执行期间打开的输出文件
+
+	// 退出码配置
+	successCodes []int         // 视为成功的非零退出码
+	exitCodeMap  map[int]error // 退出码到错误的映射
 
 	// 执行状态和控制
-	execCmd *exec.Cmd          // 真正的exec.Cmd对象（延迟创建）
-	cancel  context.CancelFunc // 超时上下文的取消函数
-	execOne atomic.Bool        // 确保只执行一次
+	execCmd   *exec.Cmd          // 真正的exec.Cmd对象（延迟创建）
+	process   Process            // 执行器启动的进程句柄
+	cancel    context.CancelFunc // 超时上下文的取消函数
+	ctxErr    error              // 命令结束时的上下文错误
+	ctxCause  error              // 命令结束时的上下文取消原因
+	finished  bool               // 命令是否已结束
+	done      chan struct{}      // 命令结束时关闭
+	startTime time.Time          // 启动时间
+	duration  time.Duration      // 执行耗时
+	exitCode  int                // 退出码
+	waitErr   error              // 执行错误(已经过judgeError处理)
+	execOne   atomic.Bool        // 确保只执行一次
 }
 
 // ############################################
@@ -200,6 +232,7 @@ func (c *Command) WithWorkDir(dir string) *Command {
 //   - 该方法会验证key是否为空, 如果为空会panic。
 //   - 该方法会验证环境变量格式，格式错误会panic。
 //   - 无需添加系统环境变量os.Environ(), 系统环境变量会自动继承.
+//   - 设置PATH后, 不通过shell执行(ShellNone)的命令会在该PATH中查找可执行文件
 //   - 此方法不是并发安全的，不要在多个goroutine中并发配置
 func (c *Command) WithEnv(key, value string) *Command {
 	if key == "" {
@@ -265,6 +298,87 @@ func (c *Command) WithTimeout(timeout time.Duration) *Command {
 	return c
 }
 
+// WithIdleTimeout 设置命令的无输出超时时间
+//
+// 参数：
+//   - timeout: time.Duration类型，标准输出和标准错误都没有新数据的最长时间
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 超时后按WithKillStrategy设置的终止策略终止命令, 通过shell派生子进程的命令建议设置Group
+//   - 超时返回的错误为*IdleTimeoutError, 可通过errors.Is(err, ErrIdleTimeout)判断, 与总超时错误区分
+//   - 该方法会验证超时时间是否小于等于0, 如果小于等于0则忽略。
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+func (c *Command) WithIdleTimeout(timeout time.Duration) *Command {
+	if timeout > 0 {
+		c.idleTimeout = timeout
+	}
+	return c
+}
+
+// WithStdoutFile 将标准输出同时写入文件
+//
+// 参数：
+//   - path: 文件路径
+//   - opts: 文件配置, 支持追加写入、文件权限和轮转策略
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 文件在命令启动时打开, 在Wait(或Exec等同步方法)返回前刷新并关闭, 异步执行时必须调用Wait
+//   - 与WithStdout设置的输出以及ExecOutput等方法的内存捕获同时生效
+//   - 打开文件失败时命令不会启动, 并返回对应的错误
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+//
+// 示例:
+//
+//	cmd := shellx.NewCmd("./server").WithStdoutFile("server.log", shellx.FileOptions{
+//		Append: true,
+//		Rotate: shellx.RotateOptions{MaxSize: 10 << 20, MaxBackups: 5, Compress: true},
+//	})
+func (c *Command) WithStdoutFile(path string, opts FileOptions) *Command {
+	c.outputFiles = append(c.outputFiles, outputFile{path: path, opts: opts, stdout: true})
+	return c
+}
+
+// WithStderrFile 将标准错误同时写入文件
+//
+// 参数：
+//   - path: 文件路径
+//   - opts: 文件配置, 支持追加写入、文件权限和轮转策略
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 文件的打开和关闭时机与WithStdoutFile相同
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+func (c *Command) WithStderrFile(path string, opts FileOptions) *Command {
+	c.outputFiles = append(c.outputFiles, outputFile{path: path, opts: opts, stderr: true})
+	return c
+}
+
+// WithCombinedFile 将标准输出和标准错误合并写入同一个文件
+//
+// 参数：
+//   - path: 文件路径
+//   - opts: 文件配置, 支持追加写入、文件权限和轮转策略
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 文件的打开和关闭时机与WithStdoutFile相同
+//   - 标准输出和标准错误按写入顺序交错, 不保证与进程内的写入顺序完全一致
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+func (c *Command) WithCombinedFile(path string, opts FileOptions) *Command {
+	c.outputFiles = append(c.outputFiles, outputFile{path: path, opts: opts, stdout: true, stderr: true})
+	return c
+}
+
 // WithContext 设置命令的上下文
 //
 // 参数：
@@ -282,6 +396,49 @@ func (c *Command) WithContext(ctx context.Context) *Command {
 	return c
 }
 
+// WithSignalForwarding 在命令执行期间将父进程收到的信号转发给子进程
+//
+// 参数：
+//   - signals: 需要转发的信号, 如os.Interrupt、syscall.SIGTERM
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 转发从进程启动开始, 到Wait(或Exec等同步方法)返回时结束, 之后恢复之前的signal.Notify状态
+//   - 转发期间父进程不再执行这些信号的默认行为(如Ctrl-C终止父进程)
+//   - 设置了KillStrategy.Group时信号转发给整个进程组(仅Unix)
+//   - Windows不支持向子进程发送os.Interrupt等信号, 转发失败会被忽略
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+//
+// 示例:
+//
+//	err := shellx.NewCmd("terraform", "apply").
+//		WithSignalForwarding(os.Interrupt, syscall.SIGTERM).
+//		Exec()
+func (c *Command) WithSignalForwarding(signals ...os.Signal) *Command {
+	c.forwardSignals = append(c.forwardSignals, signals...)
+	return c
+}
+
+// WithKillStrategy 设置命令被取消或超时时的终止策略
+//
+// 参数：
+//   - ks: KillStrategy类型，终止策略
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 仅在设置了上下文或超时的情况下, 取消时才会应用该策略
+//   - 设置了Group时, Kill和Signal方法同样作用于整个进程组(仅Unix)
+//   - 设置了Tree时, Kill和Signal方法同样作用于整个进程树(仅Linux)
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+func (c *Command) WithKillStrategy(ks KillStrategy) *Command {
+	c.killStrategy = ks
+	return c
+}
+
 // WithStdin 设置命令的标准输入
 //
 // 参数：
@@ -330,18 +487,133 @@ func (c *Command) WithStderr(stderr io.Writer) *Command {
 // WithShell 设置命令的shell类型
 //
 // 参数：
-//   - shell: ShellType类型，表示要使用的shell类型
+//   - shell: ShellType类型，表示要使用的shell类型, 可以是内置shell或通过 RegisterShell 注册的shell
 //
 // 返回：
 //   - *Command: 命令对象
 //
 // 注意:
+//   - 使用未注册的shell类型时, 执行命令会返回 ErrUnknownShell
 //   - 此方法不是并发安全的，不要在多个goroutine中并发配置
 func (c *Command) WithShell(shell ShellType) *Command {
 	c.shellType = shell
 	return c
 }
 
+// WithShellOptions 设置shell选项
+//
+// 参数：
+//   - opts: shell选项, 如 ErrExit、NoUnset、PipeFail、XTrace
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 按shell类型转换为启动参数或前置语句, 如 bash 为 -e -u -o pipefail, PowerShell 为 $ErrorActionPreference='Stop'
+//   - shell不支持的选项会在执行时返回 ErrShellOptionUnsupported, ShellNone 时忽略
+//   - 最终的参数列表可以通过 CmdStr 查看
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+//
+// 示例:
+//
+//	shellx.NewCmdStr("curl -s $URL | jq .").
+//		WithShell(shellx.ShellBash).
+//		WithShellOptions(shellx.ErrExit, shellx.NoUnset, shellx.PipeFail).
+//		Exec()
+func (c *Command) WithShellOptions(opts ...ShellOption) *Command {
+	c.shellInv.opts = append(c.shellInv.opts, opts...)
+	return c
+}
+
+// WithLoginShell 以登录shell启动 (如 -l), 会加载用户的登录配置文件
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - shell不支持时执行会返回 ErrShellOptionUnsupported, ShellNone 时忽略
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+func (c *Command) WithLoginShell() *Command {
+	c.shellInv.login = true
+	return c
+}
+
+// WithInteractive 以交互式shell启动 (如 -i), 会加载交互式配置文件 (如 ~/.bashrc) 中的别名和函数
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - shell不支持时执行会返回 ErrShellOptionUnsupported, ShellNone 时忽略
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+func (c *Command) WithInteractive() *Command {
+	c.shellInv.interactive = true
+	return c
+}
+
+// WithExecutor 设置命令的执行器
+//
+// 参数：
+//   - e: Executor类型，负责真正启动命令的执行器
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 未设置或设置为nil时使用包级默认执行器(参见SetDefaultExecutor)
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+func (c *Command) WithExecutor(e Executor) *Command {
+	c.executor = e
+	return c
+}
+
+// WithSuccessCodes 设置视为执行成功的退出码
+//
+// 参数：
+//   - codes: 退出码列表, 如grep的1(无匹配)、diff的1(存在差异)
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 退出码0始终视为成功, 多次调用会累加
+//   - 只影响返回的错误, WaitWithCode和Result仍然返回原始退出码
+//   - 上下文取消、超时等错误不受影响
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+func (c *Command) WithSuccessCodes(codes ...int) *Command {
+	c.successCodes = append(c.successCodes, codes...)
+	return c
+}
+
+// WithExitCodeMap 设置退出码到错误的映射
+//
+// 参数：
+//   - m: 退出码到错误的映射, 错误为nil表示该退出码视为成功
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 映射的错误会附带命令字符串和退出码, 可通过errors.Is判断
+//   - 映射优先于WithSuccessCodes, 多次调用会合并, 相同退出码以后设置的为准
+//   - 只影响返回的错误, WaitWithCode和Result仍然返回原始退出码
+//   - 此方法不是并发安全的，不要在多个goroutine中并发配置
+//
+// 示例:
+//
+//	var ErrVanished = errors.New("source files vanished")
+//	cmd := shellx.NewCmd("rsync", "-a", "src/", "dst/").
+//		WithExitCodeMap(map[int]error{24: ErrVanished})
+func (c *Command) WithExitCodeMap(m map[int]error) *Command {
+	if c.exitCodeMap == nil {
+		c.exitCodeMap = make(map[int]error, len(m))
+	}
+	for code, err := range m {
+		c.exitCodeMap[code] = err
+	}
+	return c
+}
+
 // ############################################
 // 属性获取方法
 // ############################################
@@ -397,7 +669,15 @@ func (c *Command) Args() []string {
 //
 // 返回:
 //   - string: 命令字符串
+//
+// 注意:
+//   - 设置了shell选项、登录或交互式配置时返回包含shell及其参数的完整参数列表,
+//     每个参数按shell的规则引用, 执行前后使用相同的解释器路径
 func (c *Command) CmdStr() string {
+	if s, ok := c.invocationStr(); ok {
+		return s
+	}
+
 	if c.execCmd == nil {
 		return c.getCmdStr()
 
@@ -442,6 +722,65 @@ func (c *Command) Timeout() time.Duration {
 	return c.timeout
 }
 
+// Context 获取命令执行使用的上下文
+//
+// 返回:
+//   - context.Context: 命令上下文, 未设置时返回context.Background()
+//
+// 注意:
+//   - 命令启动后, 若通过WithTimeout设置了超时, 返回的是内部创建的超时上下文
+//   - 此方法不是并发安全的，不要在多个goroutine中并发调用
+func (c *Command) Context() context.Context {
+	if c.userCtx == nil {
+		return context.Background()
+	}
+	return c.userCtx
+}
+
+// Stdin 获取命令的标准输入
+//
+// 返回:
+//   - io.Reader: 标准输入, 未设置时为nil
+//
+// 注意:
+//   - 此方法不是并发安全的，不要在多个goroutine中并发调用
+func (c *Command) Stdin() io.Reader {
+	return c.stdin
+}
+
+// Stdout 获取命令的标准输出
+//
+// 返回:
+//   - io.Writer: 标准输出, 未设置时为nil
+//
+// 注意:
+//   - 此方法不是并发安全的，不要在多个goroutine中并发调用
+func (c *Command) Stdout() io.Writer {
+	return c.stdout
+}
+
+// Stderr 获取命令的标准错误输出
+//
+// 返回:
+//   - io.Writer: 标准错误输出, 未设置时为nil
+//
+// 注意:
+//   - 此方法不是并发安全的，不要在多个goroutine中并发调用
+func (c *Command) Stderr() io.Writer {
+	return c.stderr
+}
+
+// Executor 获取命令实际使用的执行器
+//
+// 返回:
+//   - Executor: 通过WithExecutor设置的执行器, 未设置时为包级默认执行器
+func (c *Command) Executor() Executor {
+	if c.executor != nil {
+		return c.executor
+	}
+	return DefaultExecutor()
+}
+
 // ############################################
 // 执行方法
 // ############################################
@@ -455,16 +794,11 @@ func (c *Command) Exec() error {
 		return ErrAlreadyExecuted
 	}
 
-	// 执行时才构建真正的exec.Cmd
-	if err := c.buildExecCmd(); err != nil {
-		return err
+	if err := c.start(); err != nil {
+		return judgeError(err, c)
 	}
 
-	// 确保资源清理
-	defer c.cleanup()
-
-	err := c.execCmd.Run()
-	return judgeError(err, c)
+	return c.wait()
 }
 
 // ExecOutput 执行命令并返回合并后的输出(阻塞)
@@ -474,22 +808,29 @@ func (c *Command) Exec() error {
 //   - error: 错误信息，可通过 IsTimeoutError() 和 IsCanceledError() 判断错误类型
 //
 // 注意:
-//   - 由于需要捕获默认的stdout和stderr合并输出, 内部已经设置了WithStdout(os.Stdout)和WithStderr(os.Stderr)
+//   - 如果通过WithStdout或WithStderr设置了输出, 输出会同时写入设置的输出和返回的结果
 func (c *Command) ExecOutput() ([]byte, error) {
 	if !c.execOne.CompareAndSwap(false, true) {
 		return nil, ErrAlreadyExecuted
 	}
 
-	// 执行时才构建真正的exec.Cmd
-	if err := c.buildExecCmd(); err != nil {
-		return nil, err
+	var buf bytes.Buffer
+	if c.stdout == nil && c.stderr == nil {
+		// stdout和stderr为同一个writer时, exec包只会使用一个管道, 无需加锁
+		c.stdout = &buf
+		c.stderr = &buf
+	} else {
+		w := &lockedWriter{w: &buf}
+		c.stdout = teeWriter(c.stdout, w)
+		c.stderr = teeWriter(c.stderr, w)
 	}
 
-	// 确保资源清理
-	defer c.cleanup()
+	if err := c.start(); err != nil {
+		return nil, judgeError(err, c)
+	}
 
-	output, err := c.execCmd.CombinedOutput()
-	return output, judgeError(err, c)
+	err := c.wait()
+	return buf.Bytes(), err
 }
 
 // ExecStdout 执行命令并返回标准输出(阻塞)
@@ -497,21 +838,23 @@ func (c *Command) ExecOutput() ([]byte, error) {
 // 返回:
 //   - []byte: 标准输出
 //   - error: 错误信息，可通过 IsTimeoutError() 和 IsCanceledError() 判断错误类型
+//
+// 注意:
+//   - 如果通过WithStdout设置了输出, 标准输出会同时写入设置的输出和返回的结果
 func (c *Command) ExecStdout() ([]byte, error) {
 	if !c.execOne.CompareAndSwap(false, true) {
 		return nil, ErrAlreadyExecuted
 	}
 
-	// 执行时才构建真正的exec.Cmd
-	if err := c.buildExecCmd(); err != nil {
-		return nil, err
-	}
+	var buf bytes.Buffer
+	c.stdout = teeWriter(c.stdout, &buf)
 
-	// 确保资源清理
-	defer c.cleanup()
+	if err := c.start(); err != nil {
+		return nil, judgeError(err, c)
+	}
 
-	output, err := c.execCmd.Output()
-	return output, judgeError(err, c)
+	err := c.wait()
+	return buf.Bytes(), err
 }
 
 // ExecAsync 异步执行命令(非阻塞)
@@ -523,12 +866,7 @@ func (c *Command) ExecAsync() error {
 		return ErrAlreadyExecuted
 	}
 
-	// 执行时才构建真正的exec.Cmd
-	if err := c.buildExecCmd(); err != nil {
-		return err
-	}
-
-	err := c.execCmd.Start()
+	err := c.start()
 	return judgeError(err, c)
 }
 
@@ -537,16 +875,7 @@ func (c *Command) ExecAsync() error {
 // 返回:
 //   - error: 错误信息，可通过 IsTimeoutError() 和 IsCanceledError() 判断错误类型
 func (c *Command) Wait() error {
-	if c.execCmd == nil {
-		return ErrNotStarted
-	}
-
-	err := c.execCmd.Wait()
-
-	// 清理资源
-	c.cleanup()
-
-	return judgeError(err, c)
+	return c.wait()
 }
 
 // WaitWithCode 等待命令执行完成并返回退出码(仅在异步执行时有效)
@@ -555,25 +884,86 @@ func (c *Command) Wait() error {
 //   - int: 命令退出码(0表示成功，-1表示无法提取的执行错误，其他值表示命令返回的退出码)
 //   - error: 错误信息，可通过 IsTimeoutError() 和 IsCanceledError() 判断错误类型
 func (c *Command) WaitWithCode() (int, error) {
-	if c.execCmd == nil {
+	if c.process == nil {
 		return -1, ErrNotStarted
 	}
 
-	err := c.execCmd.Wait()
+	err := c.wait()
+	return c.exitCode, err
+}
+
+// WaitContext 等待命令执行完成, 上下文取消时放弃等待
+//
+// 参数:
+//   - ctx: 上下文, 为nil时与Wait相同
+//
+// 返回:
+//   - error: 命令结束时为执行错误, 放弃等待时为上下文错误
+//
+// 注意:
+//   - 放弃等待不会终止命令, 需要终止时请调用Kill或为命令设置上下文
+func (c *Command) WaitContext(ctx context.Context) error {
+	if c.process == nil {
+		return ErrNotStarted
+	}
+	if ctx == nil {
+		return c.wait()
+	}
+
+	select {
+	case <-c.done:
+		return c.waitErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
 
-	// 清理资源
-	c.cleanup()
+// Done 获取命令结束通道
+//
+// 返回:
+//   - <-chan struct{}: 命令结束且资源清理完成后关闭, 命令未启动时为nil
+//
+// 示例:
+//
+//	select {
+//	case <-cmd.Done():
+//		res, _ := cmd.Result()
+//	case <-time.After(time.Second):
+//	}
+func (c *Command) Done() <-chan struct{} {
+	return c.done
+}
 
-	// 获取命令的退出码
-	exitCode := extractExitCode(err)
+// Result 等待命令执行完成并返回执行结果
+//
+// 返回:
+//   - *Result: 执行结果, Stdout和Stderr为nil, 需要输出时请使用WithStdout/WithStderr或ExecOutput
+//   - error: 执行错误, 与Result.Err相同
+//
+// 注意:
+//   - 命令结束后可以多次调用, 每次返回新的结果副本
+func (c *Command) Result() (*Result, error) {
+	if c.process == nil {
+		return nil, ErrNotStarted
+	}
 
-	return exitCode, judgeError(err, c)
+	err := c.wait()
+	return &Result{
+		Cmd:      c.CmdStr(),
+		ExitCode: c.exitCode,
+		Err:      err,
+		Start:    c.startTime,
+		Duration: c.duration,
+	}, err
 }
 
 // Cmd 获取底层的 exec.Cmd 对象
 //
 // 返回:
 //   - *exec.Cmd: 底层的 exec.Cmd 对象
+//
+// 注意:
+//   - 使用非 OSExecutor 的执行器时, 返回的 exec.Cmd 不会被用于执行
 func (c *Command) Cmd() *exec.Cmd {
 	if c.execCmd == nil {
 		if err := c.buildExecCmd(); err != nil {
@@ -590,10 +980,10 @@ func (c *Command) Cmd() *exec.Cmd {
 // 返回:
 //   - error: 错误信息
 func (c *Command) Kill() error {
-	if c.execCmd == nil || c.execCmd.Process == nil {
+	if c.process == nil {
 		return ErrNoProcess
 	}
-	return c.execCmd.Process.Kill()
+	return c.process.Kill()
 }
 
 // Signal 向当前进程发送信号
@@ -604,33 +994,27 @@ func (c *Command) Kill() error {
 // 返回:
 //   - error: 错误信息
 func (c *Command) Signal(sig os.Signal) error {
-	if c.execCmd == nil || c.execCmd.Process == nil {
+	if c.process == nil {
 		return ErrNoProcess
 	}
-	return c.execCmd.Process.Signal(sig)
+	return c.process.Signal(sig)
 }
 
 // IsRunning 检查进程是否还在运行
 //
-// 注意: 此方法提供基本的进程状态检查，可能不是100%准确，
-// 特别是在Windows系统上可能存在权限问题。
-// 对于精确的进程状态管理，建议使用Wait或WaitWithCode方法。
-//
 // 返回:
-//   - bool: 是否在运行
+//   - bool: 进程已启动且尚未被回收时返回true
 func (c *Command) IsRunning() bool {
-	if c.execCmd == nil || c.execCmd.Process == nil {
+	if c.done == nil {
 		return false
 	}
 
-	// 如果ProcessState不为nil，表示进程已经结束
-	if c.execCmd.ProcessState != nil {
+	select {
+	case <-c.done:
 		return false
+	default:
+		return true
 	}
-
-	// 尝试发送信号0检查进程是否存在
-	err := c.execCmd.Process.Signal(syscall.Signal(0))
-	return err == nil
 }
 
 // GetPID 获取进程ID
@@ -638,10 +1022,10 @@ func (c *Command) IsRunning() bool {
 // 返回:
 //   - int: 进程ID, 如果进程不存在返回0
 func (c *Command) GetPID() int {
-	if c.execCmd == nil || c.execCmd.Process == nil {
+	if c.process == nil {
 		return 0
 	}
-	return c.execCmd.Process.Pid
+	return c.process.Pid()
 }
 
 // IsExecuted 检查命令是否已经执行过
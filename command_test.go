@@ -11,9 +11,12 @@ package shellx
 import (
 	"bytes"
 	"context"
+	"errors"
 	"os"
+	"runtime"
 	"strings"
 	"sync"
+	"syscall"
 	"testing"
 	"time"
 )
@@ -657,3 +660,110 @@ func TestCmd(t *testing.T) {
 		t.Error("多次调用Cmd()应该返回同一个对象")
 	}
 }
+
+// TestWithKillStrategy 测试终止策略作用于整个进程组
+func TestWithKillStrategy(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("进程组仅在Unix系统上支持")
+	}
+
+	// 后台子进程持有输出管道, 仅Kill主进程时Wait会一直阻塞
+	cmd := NewCmdStr("sleep 30 & sleep 30; wait").
+		WithTimeout(100 * time.Millisecond).
+		WithKillStrategy(KillStrategy{Signal: syscall.SIGTERM, GracePeriod: time.Second, Group: true})
+
+	begin := time.Now()
+	_, err := cmd.ExecOutput()
+	if elapsed := time.Since(begin); elapsed > 5*time.Second {
+		t.Fatalf("终止进程组后应尽快返回, 实际耗时 %v", elapsed)
+	}
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("期望超时错误, 实际为: %v", err)
+	}
+}
+
+// TestWithSuccessCodes 测试自定义成功退出码和退出码映射
+func TestWithSuccessCodes(t *testing.T) {
+	t.Run("成功退出码", func(t *testing.T) {
+		cmd := NewCmdStr("exit 1").WithSuccessCodes(1)
+		if err := cmd.ExecAsync(); err != nil {
+			t.Fatalf("启动命令失败: %v", err)
+		}
+		code, err := cmd.WaitWithCode()
+		if err != nil || code != 1 {
+			t.Errorf("期望成功并保留原始退出码 1, 实际退出码 %d, 错误: %v", code, err)
+		}
+
+		if err := NewCmdStr("exit 2").WithSuccessCodes(1).Exec(); err == nil {
+			t.Error("未声明的退出码应返回错误")
+		}
+	})
+
+	t.Run("退出码映射", func(t *testing.T) {
+		errVanished := errors.New("source files vanished")
+		codes := map[int]error{24: errVanished, 3: nil}
+
+		err := NewCmdStr("exit 24").WithExitCodeMap(codes).Exec()
+		if !errors.Is(err, errVanished) || !strings.Contains(err.Error(), "code 24") {
+			t.Errorf("期望映射为自定义错误, 实际为: %v", err)
+		}
+		if err := NewCmdStr("exit 3").WithExitCodeMap(codes).Exec(); err != nil {
+			t.Errorf("映射为nil的退出码应视为成功, 实际为: %v", err)
+		}
+	})
+}
+
+// TestWaitContext 测试可放弃的等待、完成通道和共享的等待结果
+func TestWaitContext(t *testing.T) {
+	cmd := NewCmdStr("sleep 0.3; exit 2")
+	if err := cmd.ExecAsync(); err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+	if !cmd.IsRunning() {
+		t.Error("命令启动后应处于运行状态")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := cmd.WaitContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("放弃等待时期望上下文错误, 实际为: %v", err)
+	}
+
+	// 多个协程并发等待得到相同的结果
+	var wg sync.WaitGroup
+	errs := make([]error, 3)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = cmd.Wait()
+		}(i)
+	}
+
+	select {
+	case <-cmd.Done():
+	case <-time.After(3 * time.Second):
+		t.Fatal("命令结束后完成通道应关闭")
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil || err.Error() != errs[0].Error() {
+			t.Errorf("并发等待应得到相同的错误, 实际为: %v", errs)
+		}
+	}
+	if cmd.IsRunning() {
+		t.Error("命令结束后不应处于运行状态")
+	}
+
+	for range 2 {
+		res, err := cmd.Result()
+		if err == nil || res.ExitCode != 2 || res.Duration <= 0 || res.Start.IsZero() {
+			t.Errorf("执行结果不符合预期: %+v, 错误: %v", res, err)
+		}
+	}
+
+	if _, err := NewCmd("echo").Result(); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("未启动时期望 ErrNotStarted, 实际为: %v", err)
+	}
+}
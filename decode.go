@@ -0,0 +1,335 @@
+// Package shellx 输出解码模块
+// 本文件提供了将命令标准输出直接解码为常用格式的便捷函数，包括：
+//   - ExecJSON: 将输出解析为 JSON
+//   - ExecLines/ExecLinesSeq: 按行拆分输出，后者在命令运行期间流式返回
+//   - ExecCSV: 将输出解析为 CSV 记录
+//   - ExecDecode: 使用自定义解码函数解析输出
+//
+// 解码失败时返回 *DecodeError，携带命令字符串和出错位置附近的输出片段；
+// 命令以非零退出码结束时，错误信息中会附带捕获的标准错误。
+package shellx
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+	"unicode/utf8"
+)
+
+// snippetSize 错误信息中输出片段的最大长度
+const snippetSize = 200
+
+// maxLineSize ExecLinesSeq 支持的单行最大长度
+const maxLineSize = 1024 * 1024
+
+// DecodeError 表示命令输出解码失败
+type DecodeError struct {
+	Cmd     string // 命令字符串
+	Format  string // 解码格式 (json、csv、custom等)
+	Snippet string // 出错位置附近的输出片段
+	Err     error  // 原始解码错误
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("decode %s output of %s failed: %v; output: %q", e.Format, e.Cmd, e.Err, e.Snippet)
+}
+
+// Unwrap 返回原始解码错误
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ExecJSON 执行命令并将标准输出解析为 JSON
+//
+// 参数:
+//   - c: 命令对象
+//
+// 返回:
+//   - T: 解析结果
+//   - error: 执行错误或*DecodeError
+//
+// 示例:
+//
+//	pods, err := shellx.ExecJSON[PodList](shellx.NewCmd("kubectl", "get", "pods", "-o", "json"))
+func ExecJSON[T any](c *Command) (T, error) {
+	var v T
+	out, err := execCapture(c)
+	if err != nil {
+		return v, err
+	}
+
+	if err := json.Unmarshal(out, &v); err != nil {
+		offset := int64(-1)
+		var syntaxErr *json.SyntaxError
+		var typeErr *json.UnmarshalTypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			offset = syntaxErr.Offset
+		case errors.As(err, &typeErr):
+			offset = typeErr.Offset
+		}
+		return v, newDecodeError(c, "json", out, offset, err)
+	}
+	return v, nil
+}
+
+// ExecLines 执行命令并将标准输出按行拆分
+//
+// 参数:
+//   - c: 命令对象
+//
+// 返回:
+//   - []string: 输出行, 不包含行尾的换行符(\n或\r\n)
+//   - error: 执行错误
+func ExecLines(c *Command) ([]string, error) {
+	out, err := execCapture(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	sc.Buffer(nil, max(len(out)+1, bufio.MaxScanTokenSize))
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	return lines, nil
+}
+
+// ExecLinesSeq 执行命令并在运行期间逐行返回标准输出
+//
+// 参数:
+//   - c: 命令对象
+//
+// 返回:
+//   - iter.Seq2[string, error]: 行迭代器, 出错时返回空行和错误, 之后迭代结束
+//
+// 注意:
+//   - 迭代器只能遍历一次, 再次遍历会返回ErrAlreadyExecuted
+//   - 提前结束遍历会杀死命令进程
+//   - 单行长度不能超过1MB
+//
+// 示例:
+//
+//	for line, err := range shellx.ExecLinesSeq(shellx.NewCmd("tail", "-n", "100", "app.log")) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(line)
+//	}
+func ExecLinesSeq(c *Command) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		if c.IsExecuted() {
+			yield("", ErrAlreadyExecuted)
+			return
+		}
+
+		pr, pw := io.Pipe()
+		var stderr bytes.Buffer
+		c.captureOutput(pw, &stderr)
+
+		if err := c.ExecAsync(); err != nil {
+			yield("", err)
+			return
+		}
+
+		done := make(chan error, 1)
+		go func() {
+			err := c.Wait()
+			_ = pw.Close()
+			done <- err
+		}()
+
+		// stop 提前结束时杀死进程并等待资源释放
+		stop := func() {
+			_ = c.Kill()
+			_ = pr.Close()
+			<-done
+		}
+
+		sc := bufio.NewScanner(pr)
+		sc.Buffer(nil, maxLineSize)
+		for sc.Scan() {
+			if !yield(sc.Text(), nil) {
+				stop()
+				return
+			}
+		}
+
+		if err := sc.Err(); err != nil {
+			stop()
+			yield("", newDecodeError(c, "lines", nil, -1, err))
+			return
+		}
+
+		if err := <-done; err != nil {
+			yield("", withStderr(err, stderr.Bytes()))
+		}
+	}
+}
+
+// ExecCSV 执行命令并将标准输出解析为 CSV 记录
+//
+// 参数:
+//   - c: 命令对象
+//
+// 返回:
+//   - [][]string: CSV 记录
+//   - error: 执行错误或*DecodeError
+//
+// 注意:
+//   - 使用encoding/csv的默认配置(逗号分隔, 每条记录字段数一致)
+func ExecCSV(c *Command) ([][]string, error) {
+	out, err := execCapture(c)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		offset := int64(-1)
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			offset = lineOffset(out, parseErr.StartLine)
+		}
+		return nil, newDecodeError(c, "csv", out, offset, err)
+	}
+	return records, nil
+}
+
+// ExecDecode 执行命令并使用自定义解码函数解析标准输出
+//
+// 参数:
+//   - c: 命令对象
+//   - decode: 解码函数, 接收完整的标准输出
+//
+// 返回:
+//   - error: 执行错误或*DecodeError
+//
+// 示例:
+//
+//	var cfg Config
+//	err := shellx.ExecDecode(cmd, func(data []byte) error {
+//		return yaml.Unmarshal(data, &cfg)
+//	})
+func ExecDecode(c *Command, decode func(data []byte) error) error {
+	out, err := execCapture(c)
+	if err != nil {
+		return err
+	}
+
+	if err := decode(out); err != nil {
+		return newDecodeError(c, "custom", out, -1, err)
+	}
+	return nil
+}
+
+// execCapture 执行命令并分别捕获标准输出和标准错误
+//
+// 返回:
+//   - []byte: 标准输出
+//   - error: 执行错误, 非零退出时附带标准错误
+func execCapture(c *Command) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	c.captureOutput(&stdout, &stderr)
+
+	if err := c.Exec(); err != nil {
+		return nil, withStderr(err, stderr.Bytes())
+	}
+	return stdout.Bytes(), nil
+}
+
+// withStderr 将捕获的标准错误附加到错误信息中
+//
+// 参数:
+//   - err: 执行错误
+//   - stderr: 捕获的标准错误
+//
+// 返回:
+//   - error: 包装后的错误, 保留原始错误链
+func withStderr(err error, stderr []byte) error {
+	msg := strings.TrimSpace(string(stderr))
+	if msg == "" {
+		return err
+	}
+	if len(msg) > snippetSize {
+		msg = "..." + msg[runeStart(msg, len(msg)-snippetSize):]
+	}
+	return fmt.Errorf("%w: stderr: %s", err, msg)
+}
+
+// newDecodeError 创建解码错误
+//
+// 参数:
+//   - c: 命令对象
+//   - format: 解码格式
+//   - out: 完整输出
+//   - offset: 出错位置的字节偏移, 小于0表示未知
+//   - err: 原始解码错误
+//
+// 返回:
+//   - *DecodeError: 解码错误
+func newDecodeError(c *Command, format string, out []byte, offset int64, err error) *DecodeError {
+	return &DecodeError{
+		Cmd:     c.CmdStr(),
+		Format:  format,
+		Snippet: snippet(out, offset),
+		Err:     err,
+	}
+}
+
+// snippet 截取出错位置附近的输出片段
+func snippet(out []byte, offset int64) string {
+	if len(out) <= snippetSize {
+		return string(out)
+	}
+
+	start := 0
+	if offset > 0 {
+		start = max(0, min(int(offset)-snippetSize/2, len(out)-snippetSize))
+	}
+	end := runeStart(out, start+snippetSize)
+	start = runeStart(out, start)
+	s := string(out[start:end])
+	if start > 0 {
+		s = "..." + s
+	}
+	if end < len(out) {
+		s += "..."
+	}
+	return s
+}
+
+// runeStart 将截断位置向后移动到字符边界, 避免截断多字节的 UTF-8 字符
+//
+// 参数:
+//   - s: 字符串
+//   - i: 截断位置
+//
+// 返回:
+//   - int: 不小于 i 的第一个字符起始位置, 最多移动 utf8.UTFMax-1 个字节
+func runeStart[T string | []byte](s T, i int) int {
+	for n := 0; n < utf8.UTFMax-1 && i < len(s) && !utf8.RuneStart(s[i]); n++ {
+		i++
+	}
+	return i
+}
+
+// lineOffset 计算指定行(从1开始)的起始字节偏移
+func lineOffset(out []byte, line int) int64 {
+	offset := 0
+	for i := 1; i < line; i++ {
+		j := bytes.IndexByte(out[offset:], '\n')
+		if j < 0 {
+			break
+		}
+		offset += j + 1
+	}
+	return int64(offset)
+}
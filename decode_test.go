@@ -0,0 +1,155 @@
+package shellx
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// TestExecJSON 测试解析JSON输出
+func TestExecJSON(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+		Size int    `json:"size"`
+	}
+
+	items, err := ExecJSON[[]item](NewCmdStr(`echo '[{"name":"a","size":1},{"name":"b","size":2}]'`))
+	if err != nil {
+		t.Fatalf("期望解析成功, 实际错误: %v", err)
+	}
+	if len(items) != 2 || items[1].Name != "b" || items[1].Size != 2 {
+		t.Errorf("解析结果不符合预期: %+v", items)
+	}
+
+	_, err = ExecJSON[[]item](NewCmdStr(`echo '[{"name":"a","size":"big"}]'`))
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) {
+		t.Fatalf("期望 DecodeError, 实际为: %v", err)
+	}
+	if decodeErr.Format != "json" || !strings.Contains(decodeErr.Cmd, "echo") || !strings.Contains(decodeErr.Snippet, "big") {
+		t.Errorf("解码错误信息不完整: %+v", decodeErr)
+	}
+}
+
+// TestExecLines 测试按行拆分输出
+func TestExecLines(t *testing.T) {
+	lines, err := ExecLines(NewCmdStr(`printf 'a\r\nb\n\nc'`))
+	if err != nil {
+		t.Fatalf("期望执行成功, 实际错误: %v", err)
+	}
+	if strings.Join(lines, "|") != "a|b||c" {
+		t.Errorf("拆分结果不符合预期: %q", lines)
+	}
+
+	_, err = ExecLines(NewCmdStr("echo out; echo boom >&2; exit 2"))
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("非零退出时错误应包含标准错误, 实际为: %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "code 2") {
+		t.Errorf("错误应保留退出码, 实际为: %v", err)
+	}
+}
+
+// TestExecLinesSeq 测试流式逐行读取
+func TestExecLinesSeq(t *testing.T) {
+	t.Run("完整遍历", func(t *testing.T) {
+		var got []string
+		for line, err := range ExecLinesSeq(NewCmdStr("for i in 1 2 3; do echo $i; done")) {
+			if err != nil {
+				t.Fatalf("期望执行成功, 实际错误: %v", err)
+			}
+			got = append(got, line)
+		}
+		if strings.Join(got, ",") != "1,2,3" {
+			t.Errorf("读取结果不符合预期: %v", got)
+		}
+	})
+
+	t.Run("运行期间返回", func(t *testing.T) {
+		cmd := NewCmdStr("echo first; sleep 5").WithKillStrategy(KillStrategy{Group: true})
+		begin := time.Now()
+		for line, err := range ExecLinesSeq(cmd) {
+			if err != nil || line != "first" {
+				t.Fatalf("第一行不符合预期: %q, %v", line, err)
+			}
+			break
+		}
+		if elapsed := time.Since(begin); elapsed > 3*time.Second {
+			t.Errorf("提前结束应终止命令, 实际耗时 %v", elapsed)
+		}
+	})
+
+	t.Run("非零退出", func(t *testing.T) {
+		var lastErr error
+		n := 0
+		for _, err := range ExecLinesSeq(NewCmdStr("echo 1; echo bad >&2; exit 4")) {
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			n++
+		}
+		if n != 1 || lastErr == nil || !strings.Contains(lastErr.Error(), "bad") {
+			t.Errorf("期望读取1行后返回包含标准错误的错误, 实际行数 %d, 错误: %v", n, lastErr)
+		}
+	})
+}
+
+// TestExecCSV 测试解析CSV输出
+func TestExecCSV(t *testing.T) {
+	records, err := ExecCSV(NewCmdStr(`printf 'name,size\na,1\n"b,c",2\n'`))
+	if err != nil {
+		t.Fatalf("期望解析成功, 实际错误: %v", err)
+	}
+	if len(records) != 3 || records[2][0] != "b,c" {
+		t.Errorf("解析结果不符合预期: %q", records)
+	}
+
+	_, err = ExecCSV(NewCmdStr(`printf 'a,b\nc\n'`))
+	var decodeErr *DecodeError
+	if !errors.As(err, &decodeErr) || decodeErr.Format != "csv" {
+		t.Errorf("字段数不一致时期望 DecodeError, 实际为: %v", err)
+	}
+}
+
+// TestExecDecode 测试自定义解码
+func TestExecDecode(t *testing.T) {
+	var n int
+	err := ExecDecode(NewCmd("echo", "42"), func(data []byte) error {
+		var err error
+		n, err = strconv.Atoi(strings.TrimSpace(string(data)))
+		return err
+	})
+	if err != nil || n != 42 {
+		t.Errorf("期望解析为 42, 实际为 %d, 错误: %v", n, err)
+	}
+
+	err = ExecDecode(NewCmd("echo", "x"), func(data []byte) error {
+		_, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		return err
+	})
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) || !strings.Contains(err.Error(), `"x\n"`) {
+		t.Errorf("期望包装原始错误并包含输出片段, 实际为: %v", err)
+	}
+}
+
+// TestTruncateUTF8 测试截断错误信息和输出片段时不会截断多字节字符
+func TestTruncateUTF8(t *testing.T) {
+	// "错" 占3个字节, 201个字节的前缀使截断位置落在字符中间
+	msg := "x" + strings.Repeat("错", snippetSize)
+
+	err := withStderr(errors.New("failed"), []byte(msg))
+	if !utf8.ValidString(err.Error()) {
+		t.Errorf("标准错误截断后应为合法的UTF-8: %q", err.Error())
+	}
+
+	for _, offset := range []int64{0, 1, 301} {
+		if s := snippet([]byte(msg), offset); !utf8.ValidString(s) {
+			t.Errorf("偏移 %d 的输出片段应为合法的UTF-8: %q", offset, s)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 // Package shellx 错误处理模块
 // 本文件定义了 shellx 包中的错误类型、错误变量和错误处理函数，包括：
 //   - 预定义的错误变量（超时、取消、未启动等）
+//   - ExitError 退出码错误类型
+//   - IdleTimeoutError 无输出超时错误类型
 //   - 错误消息常量定义
-//   - 智能错误判断和分类函数 judgeError
+//   - 智能错误判断和分类函数 judgeError (支持自定义成功退出码和退出码映射)
 //
 // 提供统一的错误处理机制，能够准确识别和格式化各种命令执行错误。
 package shellx
@@ -12,6 +14,9 @@ import (
 	"errors"
 	"fmt"
 	"os/exec"
+	"slices"
+	"strings"
+	"time"
 )
 
 // 预定义的错误变量
@@ -22,6 +27,8 @@ var (
 	ErrNotStarted = errors.New("command has not been started")
 	// ErrNoProcess 表示没有进程可操作
 	ErrNoProcess = errors.New("no process to operate")
+	// ErrIdleTimeout 表示命令因长时间没有输出而被终止
+	ErrIdleTimeout = errors.New("command idle timeout")
 )
 
 // UnclosedQuoteError 表示命令字符串中存在未闭合的引号
@@ -38,6 +45,48 @@ func (e *UnclosedQuoteError) GetQuoteType() rune {
 	return e.QuoteType
 }
 
+// IdleTimeoutError 表示命令在无输出超时时间内没有产生任何输出而被终止
+type IdleTimeoutError struct {
+	Cmd       string        // 命令字符串
+	Idle      time.Duration // 无输出超时时间
+	LastLines []string      // 终止前最后看到的输出行
+}
+
+func (e *IdleTimeoutError) Error() string {
+	msg := fmt.Sprintf("command idle timeout: %s produced no output for %v", e.Cmd, e.Idle)
+	if len(e.LastLines) == 0 {
+		return msg
+	}
+	return msg + ", last output:\n" + strings.Join(e.LastLines, "\n")
+}
+
+// Is 使 errors.Is(err, ErrIdleTimeout) 成立
+func (e *IdleTimeoutError) Is(target error) bool {
+	return target == ErrIdleTimeout
+}
+
+// ExitError 表示命令以非零退出码结束
+//
+// 主要供非 os/exec 的执行器 (如测试替身) 返回退出码使用,
+// os/exec 执行器返回的 *exec.ExitError 同样会被识别。
+type ExitError struct {
+	Code int // 退出码
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// ExitCode 返回退出码
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// exitCoder 能够提供退出码的错误
+type exitCoder interface {
+	ExitCode() int
+}
+
 // 错误消息常量
 const (
 	// 超时和取消错误消息
@@ -73,9 +122,15 @@ func judgeError(err error, c *Command) error {
 		cmdStr = c.CmdStr()
 	}
 
+	// 检查是否为无输出超时错误
+	var idleErr *IdleTimeoutError
+	if c != nil && errors.As(c.contextCause(), &idleErr) {
+		return idleErr
+	}
+
 	// 检查是否为用户取消错误或超时错误
-	if c != nil && c.userCtx != nil {
-		ctxErr := c.userCtx.Err()
+	if c != nil {
+		ctxErr := c.contextErr()
 		switch {
 		case errors.Is(ctxErr, context.DeadlineExceeded): // 超时错误
 			return fmt.Errorf(msgTimeoutExceeded, cmdStr, c.getEffectiveTimeout())
@@ -85,6 +140,11 @@ func judgeError(err error, c *Command) error {
 		}
 	}
 
+	// shell配置错误直接返回, 便于通过 errors.Is 判断
+	if errors.Is(err, ErrUnknownShell) || errors.Is(err, ErrShellUnavailable) || errors.Is(err, ErrShellOptionUnsupported) {
+		return err
+	}
+
 	// 检查是否为 exec 包错误
 	switch {
 	case errors.Is(err, exec.ErrDot): // 无法执行当前目录
@@ -98,8 +158,20 @@ func judgeError(err error, c *Command) error {
 	}
 
 	// 检查退出码错误
-	if exitErr, ok := err.(*exec.ExitError); ok {
+	var exitErr exitCoder
+	if errors.As(err, &exitErr) {
 		exitCode := exitErr.ExitCode()
+		if c != nil {
+			if mapped, ok := c.exitCodeMap[exitCode]; ok {
+				if mapped == nil {
+					return nil
+				}
+				return fmt.Errorf("%w: "+msgExitCode, mapped, cmdStr, exitCode)
+			}
+			if slices.Contains(c.successCodes, exitCode) {
+				return nil
+			}
+		}
 		return fmt.Errorf(msgExitCode, cmdStr, exitCode)
 	}
 
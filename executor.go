@@ -0,0 +1,149 @@
+// Package shellx 执行器模块
+// 本文件定义了命令执行器接口及其默认实现，包括：
+//   - Executor: 执行器接口，Command 的进程启动委托给执行器完成
+//   - Process: 执行器启动后返回的进程句柄接口
+//   - OSExecutor: 基于 os/exec 的默认执行器
+//   - SetDefaultExecutor/DefaultExecutor: 包级默认执行器的设置与获取
+//
+// 通过替换执行器，可以在不真正创建进程的情况下对使用 shellx 的代码进行单元测试，
+// 参见子包 shellxtest 提供的 FakeExecutor。
+package shellx
+
+import (
+	"os"
+	"os/exec"
+	"sync/atomic"
+	"syscall"
+)
+
+// Executor 命令执行器接口
+//
+// 注意:
+//   - Start 在命令的上下文和标准输入输出配置完成后调用
+//   - 实现可以通过 Command 的属性获取方法 (Name、Args、CmdStr、Context、Stdout 等) 读取命令配置
+//   - Start 返回的错误会经过 shellx 的错误分类处理
+type Executor interface {
+	// Start 启动命令并返回进程句柄
+	Start(c *Command) (Process, error)
+}
+
+// Process 执行器启动的进程句柄接口
+//
+// 注意:
+//   - Wait 返回的退出码错误应实现 ExitCode() int 方法 (如 *exec.ExitError 或 *ExitError)
+//   - 进程结束后 Signal 应返回 os.ErrProcessDone
+type Process interface {
+	// Pid 返回进程ID, 没有真实进程时可以返回0
+	Pid() int
+	// Wait 等待进程结束, 只会被调用一次
+	Wait() error
+	// Signal 向进程发送信号
+	Signal(sig os.Signal) error
+	// Kill 立即终止进程
+	Kill() error
+}
+
+// OSExecutor 基于 os/exec 的默认执行器
+type OSExecutor struct{}
+
+// Start 构建 exec.Cmd 并启动进程
+//
+// 参数:
+//   - c: 命令对象
+//
+// 返回:
+//   - Process: 进程句柄
+//   - error: 启动错误
+func (OSExecutor) Start(c *Command) (Process, error) {
+	if err := c.buildExecCmd(); err != nil {
+		return nil, err
+	}
+
+	if err := startTracked(c.execCmd); err != nil {
+		return nil, err
+	}
+
+	ks := c.killStrategy
+	return &osProcess{cmd: c.execCmd, group: ks.useGroup(), tree: ks.useTree()}, nil
+}
+
+// osProcess 基于 exec.Cmd 的进程句柄
+type osProcess struct {
+	cmd   *exec.Cmd
+	group bool        // 信号是否作用于整个进程组
+	tree  bool        // 信号是否作用于整个进程树
+	done  atomic.Bool // 进程是否已被回收
+}
+
+func (p *osProcess) Pid() int {
+	return p.cmd.Process.Pid
+}
+
+func (p *osProcess) Wait() error {
+	err := p.cmd.Wait()
+	p.done.Store(true)
+	untrack(p.cmd.Process.Pid)
+	return err
+}
+
+func (p *osProcess) Signal(sig os.Signal) error {
+	// 进程已被回收时不再发送信号, 使用原子标志以便与Wait并发调用
+	if p.done.Load() {
+		return os.ErrProcessDone
+	}
+	if p.tree {
+		_, err := signalTree(p.cmd.Process.Pid, sig, freezeOnSignal(sig), nil)
+		return err
+	}
+	return signalProcess(p.cmd.Process, sig, p.group)
+}
+
+func (p *osProcess) Kill() error {
+	if p.tree && !p.done.Load() {
+		_, err := signalTree(p.cmd.Process.Pid, syscall.SIGKILL, true, nil)
+		return err
+	}
+	return killProcess(p.cmd.Process, p.group)
+}
+
+// executorHolder 用于在 atomic.Value 中保存不同具体类型的执行器
+type executorHolder struct {
+	e Executor
+}
+
+// defaultExecutor 包级默认执行器
+var defaultExecutor atomic.Value
+
+// SetDefaultExecutor 设置包级默认执行器
+//
+// 参数:
+//   - e: 执行器, 为 nil 时恢复为 OSExecutor
+//
+// 返回:
+//   - Executor: 之前的默认执行器, 便于测试结束后恢复
+//
+// 注意:
+//   - 默认执行器作用于所有未通过 WithExecutor 指定执行器的命令, 包括便捷函数
+//   - 这是全局状态, 修改后不要在并行测试中依赖它
+func SetDefaultExecutor(e Executor) Executor {
+	if e == nil {
+		e = OSExecutor{}
+	}
+
+	prev := defaultExecutor.Swap(executorHolder{e: e})
+	if prev == nil {
+		return OSExecutor{}
+	}
+	return prev.(executorHolder).e
+}
+
+// DefaultExecutor 获取包级默认执行器
+//
+// 返回:
+//   - Executor: 当前的默认执行器
+func DefaultExecutor() Executor {
+	if v := defaultExecutor.Load(); v != nil {
+		return v.(executorHolder).e
+	}
+	return OSExecutor{}
+}
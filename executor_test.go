@@ -0,0 +1,53 @@
+package shellx
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// TestSetDefaultExecutor 测试包级默认执行器的设置与恢复
+func TestSetDefaultExecutor(t *testing.T) {
+	if _, ok := DefaultExecutor().(OSExecutor); !ok {
+		t.Fatalf("默认执行器应为 OSExecutor, 实际为 %T", DefaultExecutor())
+	}
+
+	prev := SetDefaultExecutor(nil)
+	if _, ok := prev.(OSExecutor); !ok {
+		t.Errorf("之前的执行器应为 OSExecutor, 实际为 %T", prev)
+	}
+
+	cmd := NewCmd("echo")
+	if _, ok := cmd.Executor().(OSExecutor); !ok {
+		t.Errorf("未设置执行器的命令应使用默认执行器, 实际为 %T", cmd.Executor())
+	}
+}
+
+// TestExecOutputTee 测试ExecOutput在设置了输出时同时写入设置的输出
+func TestExecOutputTee(t *testing.T) {
+	var stdout bytes.Buffer
+	out, err := NewCmd("echo", "hello").WithStdout(&stdout).ExecOutput()
+	if err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+	if strings.TrimSpace(string(out)) != "hello" {
+		t.Errorf("期望返回输出为 'hello', 实际为 %q", out)
+	}
+	if strings.TrimSpace(stdout.String()) != "hello" {
+		t.Errorf("期望设置的输出为 'hello', 实际为 %q", stdout.String())
+	}
+}
+
+// TestExitError 测试ExitError的退出码提取
+func TestExitError(t *testing.T) {
+	err := &ExitError{Code: 3}
+	if err.Error() != "exit status 3" {
+		t.Errorf("错误信息不符合预期: %s", err.Error())
+	}
+	if code := extractExitCode(err); code != 3 {
+		t.Errorf("期望退出码为 3, 实际为 %d", code)
+	}
+	if judged := judgeError(err, NewCmd("false")); !strings.Contains(judged.Error(), "exited with code 3") {
+		t.Errorf("judgeError应识别ExitError, 实际为: %v", judged)
+	}
+}
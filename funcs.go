@@ -72,6 +72,9 @@ func SplitE(cmdStr string) ([]string, error) {
 // 返回:
 //   - string: 命令的绝对路径
 //   - error: 错误信息
+//
+// 注意:
+//   - 只返回当前进程 PATH 中的第一个匹配, 需要所有匹配或指定 PATH 时使用 FindAll/FindIn
 func FindCmd(name string) (string, error) {
 	// 优先使用标准库 exec.LookPath 查找
 	path, err := exec.LookPath(name)
@@ -0,0 +1,257 @@
+// Package shellx 异步结果模块
+// 本文件定义了 Future 结构体，用于异步执行命令并获取捕获的输出，包括：
+//   - StartOutput/Go: 启动命令并捕获标准输出和标准错误，返回 Future
+//   - Future.Await: 等待命令结束，上下文取消时放弃等待
+//   - All: 等待全部命令成功，任一失败时取消其余命令
+//   - Any: 返回第一个成功的结果，并取消其余命令
+//   - Race: 返回第一个结束的结果，无论成功与否，并取消其余命令
+package shellx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+// ErrNoFutures 表示组合函数没有传入任何 Future
+var ErrNoFutures = errors.New("no futures given")
+
+// Future 异步执行命令的结果
+type Future struct {
+	cmd    *Command           // 命令对象
+	cancel context.CancelFunc // 取消命令
+	done   chan struct{}      // 命令结束时关闭
+	res    *Result            // 执行结果, done关闭后有效
+}
+
+// StartOutput 异步执行命令并捕获输出(非阻塞)
+//
+// 返回:
+//   - *Future: 异步结果, 启动失败时立即完成, 错误记录在结果中
+//
+// 注意:
+//   - 标准输出和标准错误分别捕获到结果中, 同时保留通过WithStdout/WithStderr设置的输出
+//   - 命令自身的上下文和超时仍然有效, 与Future.Cancel任意一个都会终止命令
+//
+// 示例:
+//
+//	f := shellx.NewCmd("git", "fetch").StartOutput()
+//	// ... 其他工作
+//	res, err := f.Await(ctx)
+func (c *Command) StartOutput() *Future {
+	ctx, cancel := context.WithCancel(context.Background())
+	f := &Future{cmd: c, cancel: cancel, done: make(chan struct{})}
+
+	if c.IsExecuted() {
+		f.resolve(&Result{Cmd: c.CmdStr(), ExitCode: -1, Err: ErrAlreadyExecuted})
+		return f
+	}
+
+	var stdout, stderr bytes.Buffer
+	c.captureOutput(&stdout, &stderr)
+	c.parentCtx = ctx
+
+	if err := c.ExecAsync(); err != nil {
+		f.resolve(&Result{Cmd: c.CmdStr(), ExitCode: -1, Err: err})
+		return f
+	}
+
+	go func() {
+		res, _ := c.Result()
+		res.Stdout = stdout.Bytes()
+		res.Stderr = stderr.Bytes()
+		f.resolve(res)
+	}()
+	return f
+}
+
+// Go 异步执行命令并捕获输出(非阻塞), 与 StartOutput 相同
+//
+// 返回:
+//   - *Future: 异步结果
+func (c *Command) Go() *Future {
+	return c.StartOutput()
+}
+
+// resolve 记录结果并完成 Future
+func (f *Future) resolve(res *Result) {
+	f.res = res
+	f.cancel()
+	close(f.done)
+}
+
+// Command 获取 Future 对应的命令
+func (f *Future) Command() *Command {
+	return f.cmd
+}
+
+// Done 获取完成通道
+//
+// 返回:
+//   - <-chan struct{}: 命令结束且输出捕获完成后关闭
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Cancel 取消命令
+//
+// 注意:
+//   - 按命令的终止策略终止, 不等待命令结束, 命令已结束时不做任何操作
+func (f *Future) Cancel() {
+	f.cancel()
+}
+
+// Await 等待命令结束并返回结果
+//
+// 参数:
+//   - ctx: 上下文, 取消时放弃等待(不会终止命令), 为nil时一直等待
+//
+// 返回:
+//   - *Result: 执行结果的副本, 放弃等待时为nil
+//   - error: 执行错误(与Result.Err相同)或上下文错误
+func (f *Future) Await(ctx context.Context) (*Result, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	select {
+	case <-f.done:
+		res := *f.res
+		return &res, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// All 等待所有命令执行成功
+//
+// 参数:
+//   - ctx: 上下文, 取消时取消所有命令并返回上下文错误, 为nil时使用context.Background()
+//   - futures: 异步结果列表
+//
+// 返回:
+//   - []*Result: 按传入顺序排列的结果, 失败或取消时未结束的命令对应的结果为nil
+//   - error: 第一个失败命令的错误
+//
+// 注意:
+//   - 任一命令失败时取消其余命令, 被取消的命令不会被等待
+func All(ctx context.Context, futures ...*Future) ([]*Result, error) {
+	results := make([]*Result, len(futures))
+	var failErr error
+	err := collect(ctx, futures, func(i int, res *Result) bool {
+		results[i] = res
+		failErr = res.Err
+		return res.Err != nil
+	})
+	if err != nil {
+		return results, err
+	}
+	return results, failErr
+}
+
+// Any 返回第一个执行成功的结果
+//
+// 参数:
+//   - ctx: 上下文, 取消时取消所有命令并返回上下文错误, 为nil时使用context.Background()
+//   - futures: 异步结果列表
+//
+// 返回:
+//   - *Result: 第一个成功的结果
+//   - error: 全部失败时返回使用errors.Join聚合的错误, 没有传入Future时返回ErrNoFutures
+//
+// 注意:
+//   - 得到成功结果后取消其余命令, 被取消的命令不会被等待
+func Any(ctx context.Context, futures ...*Future) (*Result, error) {
+	if len(futures) == 0 {
+		return nil, ErrNoFutures
+	}
+
+	var winner *Result
+	errs := make([]error, 0, len(futures))
+	err := collect(ctx, futures, func(_ int, res *Result) bool {
+		if res.Err == nil {
+			winner = res
+			return true
+		}
+		errs = append(errs, res.Err)
+		return false
+	})
+	if err != nil {
+		return nil, err
+	}
+	if winner != nil {
+		return winner, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Race 返回第一个结束的结果, 无论成功与否
+//
+// 参数:
+//   - ctx: 上下文, 取消时取消所有命令并返回上下文错误, 为nil时使用context.Background()
+//   - futures: 异步结果列表
+//
+// 返回:
+//   - *Result: 第一个结束的结果
+//   - error: 该结果的执行错误, 没有传入Future时返回ErrNoFutures
+//
+// 注意:
+//   - 得到结果后取消其余命令, 被取消的命令不会被等待
+func Race(ctx context.Context, futures ...*Future) (*Result, error) {
+	if len(futures) == 0 {
+		return nil, ErrNoFutures
+	}
+
+	var first *Result
+	err := collect(ctx, futures, func(_ int, res *Result) bool {
+		first = res
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return first, first.Err
+}
+
+// collect 按完成顺序处理结果, 直到全部完成或处理函数要求停止
+//
+// 参数:
+//   - ctx: 上下文
+//   - futures: 异步结果列表
+//   - handle: 处理函数, 返回true时停止并取消其余命令
+//
+// 返回:
+//   - error: 上下文被取消时返回上下文错误
+func collect(ctx context.Context, futures []*Future, handle func(i int, res *Result) bool) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// 结束时取消所有未完成的命令
+	defer func() {
+		for _, f := range futures {
+			f.Cancel()
+		}
+	}()
+
+	ready := make(chan int, len(futures))
+	for i, f := range futures {
+		go func() {
+			<-f.done
+			ready <- i
+		}()
+	}
+
+	for range futures {
+		select {
+		case i := <-ready:
+			res := *futures[i].res
+			if handle(i, &res) {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
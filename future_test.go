@@ -0,0 +1,104 @@
+package shellx
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestFuture 测试异步执行并捕获输出
+func TestFuture(t *testing.T) {
+	f := NewCmdStr("echo out; echo err >&2; exit 1").StartOutput()
+
+	res, err := f.Await(context.Background())
+	if err == nil || res.ExitCode != 1 {
+		t.Fatalf("期望退出码为 1, 实际结果: %+v, 错误: %v", res, err)
+	}
+	if string(res.Stdout) != "out\n" || string(res.Stderr) != "err\n" {
+		t.Errorf("捕获的输出不符合预期: %q, %q", res.Stdout, res.Stderr)
+	}
+
+	// 放弃等待不影响命令
+	slow := NewCmd("sleep", "0.2").WithShell(ShellNone).Go()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := slow.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("放弃等待时期望上下文错误, 实际为: %v", err)
+	}
+	if res, err := slow.Await(context.Background()); err != nil || !res.Success() {
+		t.Errorf("命令应继续执行并成功, 实际错误: %v", err)
+	}
+
+	// 启动失败时立即完成
+	cmd := NewCmd("echo")
+	_ = cmd.Exec()
+	if _, err := cmd.StartOutput().Await(context.Background()); !errors.Is(err, ErrAlreadyExecuted) {
+		t.Errorf("期望 ErrAlreadyExecuted, 实际为: %v", err)
+	}
+}
+
+// TestFutureCombinators 测试All、Any和Race
+func TestFutureCombinators(t *testing.T) {
+	sleeper := func() *Future {
+		return NewCmd("sleep", "5").WithShell(ShellNone).Go()
+	}
+
+	t.Run("All", func(t *testing.T) {
+		results, err := All(context.Background(), NewCmd("echo", "a").Go(), NewCmd("echo", "b").Go())
+		if err != nil || len(results) != 2 || strings.TrimSpace(string(results[1].Stdout)) != "b" {
+			t.Fatalf("结果不符合预期: %v, 错误: %v", results, err)
+		}
+
+		loser := sleeper()
+		begin := time.Now()
+		_, err = All(context.Background(), NewCmdStr("exit 3").Go(), loser)
+		if err == nil || !strings.Contains(err.Error(), "code 3") {
+			t.Errorf("期望返回失败命令的错误, 实际为: %v", err)
+		}
+		if res, _ := loser.Await(context.Background()); res.Success() || time.Since(begin) > 3*time.Second {
+			t.Errorf("失败后其余命令应被取消, 结果: %+v", res)
+		}
+
+		// 后面的命令先失败时, 前面未结束的命令对应的结果为nil
+		results, err = All(context.Background(), sleeper(), NewCmdStr("exit 3").Go())
+		if err == nil || !strings.Contains(err.Error(), "code 3") {
+			t.Errorf("期望返回失败命令的错误, 实际为: %v", err)
+		}
+		if len(results) != 2 || results[0] != nil || results[1] == nil {
+			t.Errorf("结果不符合预期: %v", results)
+		}
+	})
+
+	t.Run("Any", func(t *testing.T) {
+		loser := sleeper()
+		res, err := Any(context.Background(), NewCmdStr("exit 1").Go(), NewCmd("echo", "ok").Go(), loser)
+		if err != nil || strings.TrimSpace(string(res.Stdout)) != "ok" {
+			t.Fatalf("期望返回成功的结果, 实际为: %+v, 错误: %v", res, err)
+		}
+		if res, _ := loser.Await(context.Background()); res.Success() {
+			t.Error("得到成功结果后其余命令应被取消")
+		}
+
+		_, err = Any(context.Background(), NewCmdStr("exit 1").Go(), NewCmdStr("exit 2").Go())
+		if err == nil || !strings.Contains(err.Error(), "code 1") || !strings.Contains(err.Error(), "code 2") {
+			t.Errorf("全部失败时期望聚合错误, 实际为: %v", err)
+		}
+	})
+
+	t.Run("Race", func(t *testing.T) {
+		loser := sleeper()
+		_, err := Race(context.Background(), NewCmdStr("exit 4").Go(), loser)
+		if err == nil || !strings.Contains(err.Error(), "code 4") {
+			t.Errorf("期望返回第一个结束的结果, 实际为: %v", err)
+		}
+		if res, _ := loser.Await(context.Background()); res.Success() {
+			t.Error("其余命令应被取消")
+		}
+
+		if _, err := Race(context.Background()); !errors.Is(err, ErrNoFutures) {
+			t.Errorf("期望 ErrNoFutures, 实际为: %v", err)
+		}
+	})
+}
@@ -0,0 +1,190 @@
+// Package shellx 并发命令组模块
+// 本文件定义了 Group 结构体，用于并发执行多个相互独立的命令，支持：
+//   - 有界并发：SetLimit 限制同时运行的命令数量
+//   - 快速失败：SetFailFast 在任一命令失败时通过共享上下文取消其余命令
+//   - 独立捕获：每个命令的输出单独捕获，互不交错
+//   - 结果汇总：Wait 按提交顺序返回结果，并使用 errors.Join 聚合错误
+package shellx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// errFailFast 快速失败时取消共享上下文的原因
+var errFailFast = errors.New("group canceled after a command failed")
+
+// Group 并发命令组
+//
+// 注意:
+//   - 配置方法 (SetLimit、SetFailFast) 需要在调用 Go 之前完成
+//   - Wait 只能调用一次, 调用 Wait 之后不要再调用 Go
+//   - 命令被取消时按各自的终止策略终止, 参见 WithKillStrategy
+type Group struct {
+	ctx      context.Context         // 共享上下文
+	cancel   context.CancelCauseFunc // 共享上下文的取消函数
+	sem      chan struct{}           // 并发限制信号量 (nil表示不限制)
+	failFast bool                    // 是否快速失败
+
+	wg      sync.WaitGroup
+	mu      sync.Mutex
+	results []*Result // 按提交顺序保存的结果
+	errs    []error   // 按提交顺序保存的需要聚合的错误
+}
+
+// NewGroup 创建并发命令组
+//
+// 参数:
+//   - ctx: 父上下文, 取消时会取消组内所有命令, 为nil时使用context.Background()
+//
+// 返回:
+//   - *Group: 命令组
+func NewGroup(ctx context.Context) *Group {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	g := &Group{}
+	g.ctx, g.cancel = context.WithCancelCause(ctx)
+	return g
+}
+
+// SetLimit 设置同时运行的命令数量上限
+//
+// 参数:
+//   - n: 并发上限, 小于等于0表示不限制
+//
+// 返回:
+//   - *Group: 命令组
+//
+// 注意:
+//   - 在调用 Go 之后修改上限会panic
+func (g *Group) SetLimit(n int) *Group {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.results) > 0 {
+		panic("shellx: SetLimit called after Go")
+	}
+
+	if n <= 0 {
+		g.sem = nil
+	} else {
+		g.sem = make(chan struct{}, n)
+	}
+	return g
+}
+
+// SetFailFast 设置是否快速失败
+//
+// 参数:
+//   - enabled: 为true时, 任一命令失败会取消其余正在运行和尚未启动的命令
+//
+// 返回:
+//   - *Group: 命令组
+func (g *Group) SetFailFast(enabled bool) *Group {
+	g.failFast = enabled
+	return g
+}
+
+// Go 提交命令到组中执行
+//
+// 参数:
+//   - cmd: 命令对象
+//
+// 注意:
+//   - 设置了并发上限时, 没有空闲名额会阻塞直到有命令结束, 保证命令按提交顺序启动
+//   - 命令的标准输出和标准错误会被单独捕获到结果中, 同时保留通过WithStdout/WithStderr设置的输出
+//   - 命令自身的上下文和超时仍然有效, 与组的共享上下文任意一个取消都会终止命令
+func (g *Group) Go(cmd *Command) {
+	g.mu.Lock()
+	idx := len(g.results)
+	res := &Result{Cmd: cmd.CmdStr(), ExitCode: -1}
+	g.results = append(g.results, res)
+	g.errs = append(g.errs, nil)
+	g.mu.Unlock()
+
+	// 等待并发名额, 等待期间被取消则不再启动
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		case <-g.ctx.Done():
+			g.skip(idx, res)
+			return
+		}
+	}
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			defer func() { <-g.sem }()
+		}
+
+		if g.ctx.Err() != nil {
+			g.skip(idx, res)
+			return
+		}
+
+		err := runResult(cmd, g.ctx, res)
+		if err == nil {
+			return
+		}
+
+		// 快速失败取消的命令只记录到结果中, 不参与错误聚合
+		if errors.Is(context.Cause(g.ctx), errFailFast) && cmd.contextErr() != nil {
+			return
+		}
+
+		g.mu.Lock()
+		g.errs[idx] = err
+		g.mu.Unlock()
+
+		if g.failFast {
+			g.cancel(errFailFast)
+		}
+	}()
+}
+
+// skip 记录未启动的命令
+//
+// 参数:
+//   - idx: 命令的提交序号
+//   - res: 结果对象
+//
+// 注意:
+//   - 快速失败导致的跳过不参与错误聚合
+func (g *Group) skip(idx int, res *Result) {
+	res.Err = fmt.Errorf(msgCanceled, res.Cmd)
+	if errors.Is(context.Cause(g.ctx), errFailFast) {
+		return
+	}
+
+	g.mu.Lock()
+	g.errs[idx] = res.Err
+	g.mu.Unlock()
+}
+
+// Wait 等待组内所有命令执行完成
+//
+// 返回:
+//   - []Result: 按提交顺序排列的执行结果
+//   - error: 使用errors.Join聚合的错误, 全部成功时为nil
+//
+// 注意:
+//   - 快速失败模式下, 被取消的命令的错误只记录在对应结果的Err中, 不参与聚合
+func (g *Group) Wait() ([]Result, error) {
+	g.wg.Wait()
+	g.cancel(nil) // 释放共享上下文
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	results := make([]Result, len(g.results))
+	for i, res := range g.results {
+		results[i] = *res
+	}
+	return results, errors.Join(g.errs...)
+}
@@ -0,0 +1,136 @@
+package shellx
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestGroupResultsOrder 测试结果按提交顺序返回且输出独立捕获
+func TestGroupResultsOrder(t *testing.T) {
+	g := NewGroup(nil)
+	g.Go(NewCmdStr("sleep 0.2; echo first"))
+	g.Go(NewCmdStr("echo second; echo err >&2"))
+	g.Go(NewCmd("echo", "third"))
+
+	results, err := g.Wait()
+	if err != nil {
+		t.Fatalf("期望全部成功, 实际错误: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("期望3个结果, 实际为 %d", len(results))
+	}
+
+	for i, want := range []string{"first", "second", "third"} {
+		if got := strings.TrimSpace(string(results[i].Stdout)); got != want {
+			t.Errorf("结果[%d]期望输出 %q, 实际为 %q", i, want, got)
+		}
+		if !results[i].Success() || results[i].ExitCode != 0 {
+			t.Errorf("结果[%d]应执行成功: %+v", i, results[i])
+		}
+	}
+	if strings.TrimSpace(string(results[1].Stderr)) != "err" {
+		t.Errorf("期望标准错误被单独捕获, 实际为 %q", results[1].Stderr)
+	}
+}
+
+// TestGroupErrorsJoin 测试错误聚合
+func TestGroupErrorsJoin(t *testing.T) {
+	g := NewGroup(nil)
+	g.Go(NewCmdStr("exit 1"))
+	g.Go(NewCmd("echo", "ok"))
+	g.Go(NewCmdStr("exit 2"))
+
+	results, err := g.Wait()
+	if err == nil {
+		t.Fatal("期望返回聚合错误")
+	}
+
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) || len(joined.Unwrap()) != 2 {
+		t.Errorf("期望聚合2个错误, 实际为: %v", err)
+	}
+	if results[0].ExitCode != 1 || results[1].ExitCode != 0 || results[2].ExitCode != 2 {
+		t.Errorf("退出码不符合预期: %d %d %d", results[0].ExitCode, results[1].ExitCode, results[2].ExitCode)
+	}
+}
+
+// TestGroupLimit 测试并发上限
+func TestGroupLimit(t *testing.T) {
+	var running, peak atomic.Int32
+	track := &trackWriter{running: &running, peak: &peak}
+
+	g := NewGroup(nil).SetLimit(2)
+	for i := 0; i < 6; i++ {
+		g.Go(NewCmdStr("echo start; sleep 0.1; echo end").WithStdout(track.child()))
+	}
+
+	if _, err := g.Wait(); err != nil {
+		t.Fatalf("期望全部成功, 实际错误: %v", err)
+	}
+	if p := peak.Load(); p > 2 {
+		t.Errorf("同时运行的命令数量不应超过2, 实际峰值为 %d", p)
+	}
+}
+
+// TestGroupFailFast 测试快速失败取消其余命令
+func TestGroupFailFast(t *testing.T) {
+	g := NewGroup(nil).SetFailFast(true).SetLimit(2)
+	g.Go(NewCmd("sleep", "5").WithShell(ShellNone))
+	g.Go(NewCmdStr("sleep 0.1; exit 3"))
+	g.Go(NewCmd("sleep", "5").WithShell(ShellNone))
+
+	begin := time.Now()
+	results, err := g.Wait()
+	if elapsed := time.Since(begin); elapsed > 3*time.Second {
+		t.Fatalf("快速失败应尽快取消其余命令, 实际耗时 %v", elapsed)
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "exited with code 3") {
+		t.Errorf("聚合错误应只包含失败的命令, 实际为: %v", err)
+	}
+	if strings.Contains(err.Error(), "canceled") {
+		t.Errorf("被取消的命令不应参与错误聚合, 实际为: %v", err)
+	}
+	if results[0].Err == nil || results[2].Err == nil {
+		t.Error("被取消的命令应在结果中记录错误")
+	}
+	if !results[2].Start.IsZero() {
+		t.Error("等待名额期间被取消的命令不应启动")
+	}
+}
+
+// trackWriter 根据输出记录同时运行的命令数量
+type trackWriter struct {
+	running *atomic.Int32
+	peak    *atomic.Int32
+}
+
+func (w *trackWriter) child() *trackChild {
+	return &trackChild{parent: w}
+}
+
+// trackChild 单个命令的输出跟踪
+type trackChild struct {
+	parent *trackWriter
+}
+
+func (c *trackChild) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(strings.TrimSpace(string(p)), "\n") {
+		switch line {
+		case "start":
+			n := c.parent.running.Add(1)
+			for {
+				old := c.parent.peak.Load()
+				if n <= old || c.parent.peak.CompareAndSwap(old, n) {
+					break
+				}
+			}
+		case "end":
+			c.parent.running.Add(-1)
+		}
+	}
+	return len(p), nil
+}
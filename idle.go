@@ -0,0 +1,136 @@
+// Package shellx 无输出超时模块
+// 本文件实现了 WithIdleTimeout 的输出活动监控，包括：
+//   - idleTracker: 记录最近一次输出的时间和最后若干行输出
+//   - idleWriter: 包装标准输出和标准错误，写入时刷新活动时间
+//   - watchIdle: 监控协程，超过无输出超时时间后取消命令上下文
+//
+// 超时通过带原因的上下文取消触发，复用命令的终止策略和错误判断流程。
+package shellx
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idleLastLines 无输出超时错误中保留的最后输出行数
+const idleLastLines = 10
+
+// idleTracker 输出活动记录器
+type idleTracker struct {
+	mu      sync.Mutex
+	last    time.Time    // 最近一次输出的时间
+	lines   []string     // 最后若干行完整输出
+	partial bytes.Buffer // 尚未结束的行
+}
+
+// touch 记录输出数据
+func (t *idleTracker) touch(p []byte) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.last = time.Now()
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			t.partial.Write(p)
+			break
+		}
+		t.partial.Write(p[:i])
+		t.pushLine(strings.TrimRight(t.partial.String(), "\r"))
+		t.partial.Reset()
+		p = p[i+1:]
+	}
+}
+
+// pushLine 保存一行输出, 只保留最后idleLastLines行, 调用方需持有锁
+func (t *idleTracker) pushLine(line string) {
+	if len(t.lines) == idleLastLines {
+		copy(t.lines, t.lines[1:])
+		t.lines = t.lines[:idleLastLines-1]
+	}
+	t.lines = append(t.lines, line)
+}
+
+// snapshot 获取最近一次输出时间和最后若干行输出
+func (t *idleTracker) snapshot() (time.Time, []string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	lines := append([]string{}, t.lines...)
+	if t.partial.Len() > 0 {
+		lines = append(lines, t.partial.String())
+		if len(lines) > idleLastLines {
+			lines = lines[1:]
+		}
+	}
+	return t.last, lines
+}
+
+// idleWriter 在写入时刷新输出活动时间的写入器
+type idleWriter struct {
+	t *idleTracker
+	w io.Writer
+}
+
+func (w *idleWriter) Write(p []byte) (int, error) {
+	w.t.touch(p)
+	if w.w == nil {
+		return len(p), nil
+	}
+	return w.w.Write(p)
+}
+
+// watchIdle 包装输出并启动无输出超时监控
+//
+// 注意:
+//   - 在prepareContext之后调用, 会在命令上下文之上派生可带原因取消的上下文
+//   - 监控协程在命令上下文结束(包括执行完成后的资源清理)时退出
+func (c *Command) watchIdle() {
+	t := &idleTracker{last: time.Now()}
+
+	// stdout和stderr为同一个输出时共用一个包装器, 保持exec包只使用一个管道
+	out := &idleWriter{t: t, w: c.stdout}
+	errOut := out
+	if c.stdout != c.stderr {
+		errOut = &idleWriter{t: t, w: c.stderr}
+	}
+	c.stdout, c.stderr = out, errOut
+
+	ctx, cancel := context.WithCancelCause(c.Context())
+	parentCancel := c.cancel
+	c.cancel = func() {
+		cancel(nil)
+		if parentCancel != nil {
+			parentCancel()
+		}
+	}
+	c.userCtx = ctx
+
+	idle := c.idleTimeout
+	cmdStr := c.getCmdStr()
+	go func() {
+		timer := time.NewTimer(idle)
+		defer timer.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			last, lines := t.snapshot()
+			if wait := idle - time.Since(last); wait > 0 {
+				timer.Reset(wait)
+				continue
+			}
+
+			cancel(&IdleTimeoutError{Cmd: cmdStr, Idle: idle, LastLines: lines})
+			return
+		}
+	}()
+}
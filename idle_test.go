@@ -0,0 +1,80 @@
+package shellx
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWithIdleTimeout 测试无输出超时
+func TestWithIdleTimeout(t *testing.T) {
+	t.Run("无输出时终止", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("进程组仅在Unix系统上支持")
+		}
+
+		// shell派生的sleep会持有输出管道, 需要终止整个进程组
+		cmd := NewCmdStr("echo step1; echo step2; sleep 5").
+			WithIdleTimeout(200 * time.Millisecond).
+			WithTimeout(10 * time.Second).
+			WithKillStrategy(KillStrategy{Group: true})
+
+		begin := time.Now()
+		_, err := cmd.ExecOutput()
+		if elapsed := time.Since(begin); elapsed > 3*time.Second {
+			t.Fatalf("无输出超时后应尽快返回, 实际耗时 %v", elapsed)
+		}
+
+		if !errors.Is(err, ErrIdleTimeout) {
+			t.Fatalf("期望无输出超时错误, 实际为: %v", err)
+		}
+		var idleErr *IdleTimeoutError
+		if !errors.As(err, &idleErr) {
+			t.Fatalf("期望 *IdleTimeoutError, 实际为 %T", err)
+		}
+		if strings.Join(idleErr.LastLines, ",") != "step1,step2" {
+			t.Errorf("期望最后输出为 [step1 step2], 实际为 %v", idleErr.LastLines)
+		}
+		if strings.Contains(err.Error(), "exceeded the") {
+			t.Errorf("无输出超时错误应与总超时错误区分, 实际为: %v", err)
+		}
+	})
+
+	t.Run("持续输出不超时", func(t *testing.T) {
+		err := NewCmdStr("for i in 1 2 3 4 5 6; do echo $i; sleep 0.05; done").
+			WithIdleTimeout(200 * time.Millisecond).
+			Exec()
+		if err != nil {
+			t.Errorf("持续输出的命令不应超时, 实际错误: %v", err)
+		}
+	})
+
+	t.Run("总超时仍然生效", func(t *testing.T) {
+		err := NewCmdStr("while true; do echo tick; sleep 0.05; done").
+			WithIdleTimeout(time.Second).
+			WithTimeout(200 * time.Millisecond).
+			Exec()
+		if err == nil || errors.Is(err, ErrIdleTimeout) || !strings.Contains(err.Error(), "timeout") {
+			t.Errorf("期望总超时错误, 实际为: %v", err)
+		}
+	})
+}
+
+// TestIdleTrackerLines 测试最后输出行的保留
+func TestIdleTrackerLines(t *testing.T) {
+	tr := &idleTracker{}
+	for i := 0; i < idleLastLines+5; i++ {
+		tr.touch([]byte("line\n"))
+	}
+	tr.touch([]byte("partial"))
+
+	_, lines := tr.snapshot()
+	if len(lines) != idleLastLines {
+		t.Fatalf("期望保留 %d 行, 实际为 %d", idleLastLines, len(lines))
+	}
+	if lines[len(lines)-1] != "partial" {
+		t.Errorf("未结束的行应包含在最后, 实际为 %q", lines[len(lines)-1])
+	}
+}
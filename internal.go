@@ -1,19 +1,66 @@
 // Package shellx 内部实现模块
 // 本文件包含 Command 结构体的内部实现方法，包括：
 //   - buildExecCmd: 延迟构建 exec.Cmd 对象，支持上下文和超时控制
-//   - cleanup: 资源清理函数，确保上下文取消函数被正确调用
+//   - cleanup: 资源清理函数，确保上下文取消函数被正确调用，并删除脚本的临时目录
 //   - getCmdStr: 命令字符串获取函数，支持原始字符串和参数拼接
+//   - start/wait: 通过执行器启动和等待进程
+//   - runResult: 执行命令并填充 Result，供 Group、Pipeline 等复用
+//   - lockedWriter/teeWriter: 输出捕获辅助类型
 //
 // 这些方法为 Command 的核心功能提供底层支持。
 package shellx
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os/exec"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 )
 
+// prepareContext 准备命令执行使用的上下文
+//
+// 注意:
+//   - 用户设置了上下文时直接使用用户上下文(忽略timeout)
+//   - 只设置了超时时创建超时上下文, 并保存到userCtx, 方便错误判断
+//   - 绑定了父上下文时, 父上下文取消同样会取消命令
+//   - 重复调用不会重复创建上下文
+//   - 此方法不是并发安全的，不要在多个goroutine中并发调用
+func (c *Command) prepareContext() {
+	parent := c.parentCtx
+	c.parentCtx = nil // 父上下文只绑定一次
+
+	switch {
+	case c.userCtx != nil && parent != nil:
+		// 用户上下文和父上下文任意一个取消都会取消命令
+		ctx, cancel := context.WithCancel(c.userCtx)
+		stop := context.AfterFunc(parent, cancel)
+		c.cancel = func() { stop(); cancel() }
+		c.userCtx = ctx
+
+	case c.userCtx != nil:
+		// 直接使用用户上下文
+
+	case c.timeout > 0:
+		if parent == nil {
+			parent = context.Background()
+		}
+		ctx, cancel := context.WithTimeout(parent, c.timeout)
+		c.cancel = cancel // 保存cancel函数用于资源清理
+		c.userCtx = ctx   // 将内部创建的上下文保存到userCtx，方便错误判断
+
+	case parent != nil:
+		ctx, cancel := context.WithCancel(parent)
+		c.cancel = cancel
+		c.userCtx = ctx
+	}
+}
+
 // buildExecCmd 在执行时构建真正的exec.Cmd对象
 //
 // 注意:
@@ -28,37 +75,31 @@ func (c *Command) buildExecCmd() error {
 		return nil // 已经构建过了
 	}
 
-	// 根据实际情况选择创建方式，避免不必要的上下文使用
-	if c.userCtx != nil {
-		// 用户设置了上下文，使用CommandContext(忽略timeout)
-		if c.shellType != ShellNone {
-			cmdStr := c.getCmdStr()
-			c.execCmd = exec.CommandContext(c.userCtx, c.shellType.String(), c.shellType.shellFlags(), cmdStr)
-		} else {
-			c.execCmd = exec.CommandContext(c.userCtx, c.name, c.args...)
+	// 通过shell执行时从注册表获取shell的可执行文件和参数
+	// 执行脚本时由 shebang 或shell类型决定解释器
+	name, args := c.name, c.args
+	switch {
+	case c.script != nil:
+		var err error
+		if name, args, err = c.scriptCommand(); err != nil {
+			return err
 		}
 
-	} else if c.timeout > 0 {
-		// 只设置了超时，创建超时上下文
-		ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
-		c.cancel = cancel // 保存cancel函数用于资源清理
-		c.userCtx = ctx   // 将内部创建的上下文保存到userCtx，方便错误判断
-
-		if c.shellType != ShellNone {
-			cmdStr := c.getCmdStr()
-			c.execCmd = exec.CommandContext(ctx, c.shellType.String(), c.shellType.shellFlags(), cmdStr)
-		} else {
-			c.execCmd = exec.CommandContext(ctx, c.name, c.args...)
+	case c.shellType != ShellNone:
+		var err error
+		if name, args, err = c.shellType.command(c.getCmdStr(), c.shellInv); err != nil {
+			return err
 		}
+	}
 
+	// 根据实际情况选择创建方式，避免不必要的上下文使用
+	c.prepareContext()
+	if c.userCtx != nil {
+		// 设置了上下文(用户上下文或超时上下文)，使用CommandContext
+		c.execCmd = exec.CommandContext(c.userCtx, name, args...)
 	} else {
 		// 都没有设置，使用普通的Command(不带上下文)
-		if c.shellType != ShellNone {
-			cmdStr := c.getCmdStr()
-			c.execCmd = exec.Command(c.shellType.String(), c.shellType.shellFlags(), cmdStr)
-		} else {
-			c.execCmd = exec.Command(c.name, c.args...)
-		}
+		c.execCmd = exec.Command(name, args...)
 	}
 
 	// 设置exec.Cmd的其他属性
@@ -68,6 +109,178 @@ func (c *Command) buildExecCmd() error {
 	c.execCmd.Stdout = c.stdout // 设置标准输出
 	c.execCmd.Stderr = c.stderr // 设置标准错误输出
 
+	// 使用命令自身环境变量中的PATH解析可执行文件, 通过shell执行时由shell自行查找
+	if c.shellType == ShellNone && c.script == nil {
+		resolveExecPath(c.execCmd, c.envs)
+	}
+
+	// 启用收割模式时注入孤儿标记, 用于识别由该命令派生的孤儿进程
+	if SubreaperEnabled() {
+		c.orphanToken = newOrphanToken()
+		c.execCmd.Env = append(slices.Clip(c.envs), orphanEnvKey+"="+c.orphanToken)
+	}
+
+	// 设置终止策略
+	if c.killStrategy.useGroup() {
+		setProcessGroup(c.execCmd)
+	}
+	if c.userCtx != nil {
+		c.execCmd.Cancel = c.terminateFunc(c.execCmd)
+		c.execCmd.WaitDelay = c.killStrategy.GracePeriod
+	}
+
+	return nil
+}
+
+// terminateFunc 根据终止策略生成exec.Cmd的Cancel函数
+//
+// 参数:
+//   - cmd: exec.Cmd对象
+//
+// 返回:
+//   - func() error: 上下文取消时调用的终止函数
+//
+// 注意:
+//   - 未设置信号时直接Kill
+//   - 设置了信号和等待时间时, exec包会在WaitDelay后Kill主进程,
+//     作用于进程组时额外在等待时间后Kill整个进程组, 清理残留的子进程
+//   - 作用于进程树时记录根进程的启动时间, 命令结束后停止等待中的强制杀死
+func (c *Command) terminateFunc(cmd *exec.Cmd) func() error {
+	ks := c.killStrategy
+	if ks.useTree() {
+		t := &treeTerminator{}
+		c.terminator = t
+		return func() error {
+			pid := cmd.Process.Pid
+			if ks.Signal == nil {
+				return t.kill(pid)
+			}
+
+			err := t.signal(pid, ks.Signal)
+			if ks.GracePeriod > 0 {
+				t.killAfter(pid, ks.GracePeriod)
+			}
+			return err
+		}
+	}
+
+	group := ks.useGroup()
+	return func() error {
+		if ks.Signal == nil {
+			return killProcess(cmd.Process, group)
+		}
+
+		err := signalProcess(cmd.Process, ks.Signal, group)
+		if group && ks.GracePeriod > 0 {
+			time.AfterFunc(ks.GracePeriod, func() {
+				_ = killProcess(cmd.Process, true)
+			})
+		}
+		return err
+	}
+}
+
+// start 通过执行器启动命令
+//
+// 返回:
+//   - error: 启动错误(未经过judgeError处理)
+func (c *Command) start() error {
+	c.prepareContext()
+	if err := c.openOutputFiles(); err != nil {
+		c.finish()
+		return err
+	}
+	if c.idleTimeout > 0 {
+		c.watchIdle()
+	}
+
+	c.startTime = time.Now()
+	proc, err := c.Executor().Start(c)
+	if err != nil {
+		c.finish()
+		return err
+	}
+
+	c.process = proc
+	c.done = make(chan struct{})
+	c.startSignalForwarding()
+	go c.reap()
+	return nil
+}
+
+// reap 等待进程结束, 清理资源并记录执行结果, 最后关闭完成通道
+//
+// 注意:
+//   - 每个命令只有一个reap协程调用Process.Wait, 所有等待方共享其结果
+func (c *Command) reap() {
+	err := c.process.Wait()
+	if c.terminator != nil {
+		// 根进程已被回收, PID可能被复用, 不能再按PID发送信号
+		c.terminator.stop()
+	}
+
+	// 记录上下文状态并清理资源
+	c.finish()
+
+	c.exitCode = extractExitCode(err)
+	c.waitErr = judgeError(err, c)
+	c.duration = time.Since(c.startTime)
+	close(c.done)
+}
+
+// wait 等待命令结束
+//
+// 返回:
+//   - error: 执行错误(已经过judgeError处理), 多次调用返回相同的结果
+func (c *Command) wait() error {
+	if c.process == nil {
+		return ErrNotStarted
+	}
+
+	<-c.done
+	return c.waitErr
+}
+
+// finish 在命令结束时记录上下文错误并清理资源
+//
+// 注意:
+//   - 清理资源会取消内部创建的上下文, 因此需要先记录上下文错误供judgeError判断
+func (c *Command) finish() {
+	if c.userCtx != nil {
+		c.ctxErr = c.userCtx.Err()
+		c.ctxCause = context.Cause(c.userCtx)
+	}
+	c.finished = true
+	c.stopSignalForwarding()
+	c.cleanup()
+	c.closeOutputFiles()
+}
+
+// contextErr 获取用于错误判断的上下文错误
+//
+// 返回:
+//   - error: 命令结束时的上下文错误, 命令未结束时为当前的上下文错误
+func (c *Command) contextErr() error {
+	if c.finished {
+		return c.ctxErr
+	}
+	if c.userCtx != nil {
+		return c.userCtx.Err()
+	}
+	return nil
+}
+
+// contextCause 获取用于错误判断的上下文取消原因
+//
+// 返回:
+//   - error: 命令结束时的取消原因, 命令未结束时为当前的取消原因
+func (c *Command) contextCause() error {
+	if c.finished {
+		return c.ctxCause
+	}
+	if c.userCtx != nil {
+		return context.Cause(c.userCtx)
+	}
 	return nil
 }
 
@@ -77,6 +290,7 @@ func (c *Command) cleanup() {
 		c.cancel()
 		c.cancel = nil
 	}
+	c.script.cleanup() // 删除脚本的临时目录
 }
 
 // getCmdStr 获取命令字符串
@@ -113,8 +327,9 @@ func extractExitCode(err error) int {
 		return 0
 	}
 
-	// 尝试从ExitError中提取真实的退出码
-	if exitErr, ok := err.(*exec.ExitError); ok {
+	// 尝试从ExitError(包括*exec.ExitError和*ExitError)中提取真实的退出码
+	var exitErr exitCoder
+	if errors.As(err, &exitErr) {
 		return exitErr.ExitCode()
 	}
 
@@ -150,3 +365,77 @@ func validateEnvVar(env string) error {
 	// 注意：value 可以为空，这是合法的（例如：KEY= 用于取消环境变量）
 	return nil
 }
+
+// lockedWriter 并发安全的写入器, 用于多个输出流写入同一个缓冲区
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// runResult 在父上下文下执行命令并填充执行结果
+//
+// 参数:
+//   - cmd: 命令对象
+//   - parent: 父上下文, 取消时终止命令
+//   - res: 结果对象
+//
+// 返回:
+//   - error: 执行错误, 同时记录在res.Err中
+func runResult(cmd *Command, parent context.Context, res *Result) error {
+	var stdout, stderr bytes.Buffer
+	cmd.captureOutput(&stdout, &stderr)
+	cmd.parentCtx = parent
+
+	res.Start = time.Now()
+	err := cmd.ExecAsync()
+	if err == nil {
+		res.ExitCode, err = cmd.WaitWithCode()
+	}
+	res.Duration = time.Since(res.Start)
+	res.Cmd = cmd.CmdStr()
+	res.Stdout = stdout.Bytes()
+	res.Stderr = stderr.Bytes()
+	res.Err = err
+
+	return err
+}
+
+// captureOutput 在保留用户设置的输出的同时捕获标准输出和标准错误
+//
+// 参数:
+//   - stdout: 捕获标准输出的写入器
+//   - stderr: 捕获标准错误的写入器
+//
+// 注意:
+//   - 用户为stdout和stderr设置了同一个输出时, 会加锁避免拆分后的并发写入
+func (c *Command) captureOutput(stdout, stderr io.Writer) {
+	userOut, userErr := c.stdout, c.stderr
+	if userOut != nil && userOut == userErr {
+		shared := &lockedWriter{w: userOut}
+		userOut, userErr = shared, shared
+	}
+
+	c.stdout = teeWriter(userOut, stdout)
+	c.stderr = teeWriter(userErr, stderr)
+}
+
+// teeWriter 将用户设置的输出与捕获输出合并
+//
+// 参数:
+//   - user: 用户设置的输出, 可以为nil
+//   - capture: 捕获输出
+//
+// 返回:
+//   - io.Writer: 合并后的输出
+func teeWriter(user, capture io.Writer) io.Writer {
+	if user == nil {
+		return capture
+	}
+	return io.MultiWriter(user, capture)
+}
@@ -0,0 +1,506 @@
+// Package shellx 后台任务管理模块
+// 本文件定义了 JobManager 结构体，用于统一管理异步执行的命令，支持：
+//   - 任务登记：以ID和元数据登记后台命令，自动捕获输出
+//   - 状态查询：List/Get 获取任务状态、PID、开始时间和运行时长
+//   - 任务控制：Kill 终止任务，Wait 等待任务结束
+//   - 优雅关闭：Shutdown 按各命令的终止策略停止所有任务，超时后强制杀死
+//   - 结果保留：任务结束后的输出和结果在保留期内仍可获取
+package shellx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrJobNotFound 表示任务不存在或已超过保留期被清理
+	ErrJobNotFound = errors.New("job not found")
+	// ErrJobExists 表示任务ID已被使用
+	ErrJobExists = errors.New("job already exists")
+	// ErrManagerClosed 表示任务管理器已关闭
+	ErrManagerClosed = errors.New("job manager is shut down")
+)
+
+// JobStatus 任务状态
+type JobStatus int
+
+const (
+	JobRunning   JobStatus = iota // 正在运行
+	JobSucceeded                  // 执行成功
+	JobFailed                     // 执行失败
+	JobKilled                     // 被 Kill 或 Shutdown 终止
+)
+
+// String 返回任务状态的字符串表示
+func (s JobStatus) String() string {
+	switch s {
+	case JobRunning:
+		return "running"
+
+	case JobSucceeded:
+		return "succeeded"
+
+	case JobFailed:
+		return "failed"
+
+	case JobKilled:
+		return "killed"
+
+	default:
+		return "unknown"
+	}
+}
+
+// JobInfo 任务信息快照
+type JobInfo struct {
+	ID       string            // 任务ID
+	Cmd      string            // 命令字符串
+	Meta     map[string]string // 元数据
+	Status   JobStatus         // 任务状态
+	PID      int               // 进程ID
+	Start    time.Time         // 开始时间
+	End      time.Time         // 结束时间, 运行中为零值
+	Runtime  time.Duration     // 运行时长, 运行中为截至当前的时长
+	ExitCode int               // 退出码, 运行中为-1
+	Err      error             // 执行错误
+}
+
+// Job 后台任务
+type Job struct {
+	id     string        // 任务ID
+	cmd    *Command      // 任务命令
+	cancel func()        // 取消任务上下文
+	done   chan struct{} // 任务结束时关闭
+	stdout lockedBuffer  // 捕获的标准输出
+	stderr lockedBuffer  // 捕获的标准错误
+
+	mu     sync.Mutex
+	info   JobInfo // 任务信息
+	killed bool    // 是否被主动终止
+}
+
+// ID 获取任务ID
+func (j *Job) ID() string {
+	return j.id
+}
+
+// Info 获取任务信息快照
+//
+// 返回:
+//   - JobInfo: 任务信息
+func (j *Job) Info() JobInfo {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	info := j.info
+	if info.Status == JobRunning {
+		info.Runtime = time.Since(info.Start)
+	}
+	return info
+}
+
+// Stdout 获取已捕获的标准输出
+//
+// 返回:
+//   - []byte: 标准输出的副本, 任务运行中时为截至当前的输出
+func (j *Job) Stdout() []byte {
+	return j.stdout.Bytes()
+}
+
+// Stderr 获取已捕获的标准错误
+//
+// 返回:
+//   - []byte: 标准错误的副本, 任务运行中时为截至当前的输出
+func (j *Job) Stderr() []byte {
+	return j.stderr.Bytes()
+}
+
+// Done 获取任务结束通道
+//
+// 返回:
+//   - <-chan struct{}: 任务结束时关闭
+func (j *Job) Done() <-chan struct{} {
+	return j.done
+}
+
+// kill 标记任务被主动终止并取消任务上下文
+func (j *Job) kill() {
+	j.markKilled()
+	j.cancel()
+}
+
+// stop 标记任务被主动终止并请求任务优雅退出
+//
+// 注意:
+//   - 发送命令终止策略中的信号, 未设置时发送SIGTERM; 信号作用于终止策略指定的进程组或进程树
+//   - 平台不支持该信号 (如Windows) 时直接强制杀死
+func (j *Job) stop() {
+	if !j.markKilled() {
+		return
+	}
+
+	sig := j.cmd.killStrategy.Signal
+	if sig == nil {
+		sig = syscall.SIGTERM
+	}
+	if err := j.cmd.Signal(sig); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		j.forceKill()
+	}
+}
+
+// forceKill 强制杀死任务, 按终止策略作用于整个进程组或进程树
+func (j *Job) forceKill() {
+	select {
+	case <-j.done:
+	default:
+		j.markKilled()
+		_ = j.cmd.Kill()
+	}
+}
+
+// markKilled 将运行中的任务标记为被主动终止
+//
+// 返回:
+//   - bool: 任务是否仍在运行
+func (j *Job) markKilled() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.info.Status != JobRunning {
+		return false
+	}
+	j.killed = true
+	return true
+}
+
+// monitor 等待任务结束并记录结果
+func (j *Job) monitor() {
+	code, err := j.cmd.WaitWithCode()
+	j.cancel()
+
+	j.mu.Lock()
+	j.info.End = time.Now()
+	j.info.Runtime = j.info.End.Sub(j.info.Start)
+	j.info.ExitCode = code
+	j.info.Err = err
+	switch {
+	case j.killed:
+		j.info.Status = JobKilled
+	case err != nil:
+		j.info.Status = JobFailed
+	default:
+		j.info.Status = JobSucceeded
+	}
+	j.mu.Unlock()
+
+	close(j.done)
+}
+
+// lockedBuffer 并发安全的缓冲区
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// Bytes 返回缓冲区内容的副本
+func (b *lockedBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return bytes.Clone(b.buf.Bytes())
+}
+
+// JobManager 后台任务管理器
+//
+// 注意:
+//   - 所有方法都是并发安全的
+//   - 任务被取消时按各自命令的终止策略终止, 参见 WithKillStrategy
+type JobManager struct {
+	ctx       context.Context    // 所有任务共享的上下文
+	cancel    context.CancelFunc // 关闭时取消所有任务
+	retention time.Duration      // 结束任务的保留时间
+
+	mu       sync.Mutex
+	jobs     map[string]*Job     // 任务索引
+	order    []*Job              // 按登记顺序排列的任务
+	pending  map[string]struct{} // 正在启动的任务ID
+	starting sync.WaitGroup      // 正在启动的任务
+	seq      int                 // 自动生成ID的序号
+	closed   bool                // 是否已关闭
+}
+
+// NewJobManager 创建后台任务管理器
+//
+// 返回:
+//   - *JobManager: 任务管理器
+func NewJobManager() *JobManager {
+	m := &JobManager{jobs: make(map[string]*Job), pending: make(map[string]struct{})}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	return m
+}
+
+// SetRetention 设置结束任务的保留时间
+//
+// 参数:
+//   - d: 保留时间, 任务结束超过该时间后被清理, 小于等于0表示一直保留
+//
+// 返回:
+//   - *JobManager: 任务管理器
+func (m *JobManager) SetRetention(d time.Duration) *JobManager {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.retention = d
+	return m
+}
+
+// Start 登记并异步启动命令
+//
+// 参数:
+//   - id: 任务ID, 为空时自动生成 (job-1, job-2 ...)
+//   - cmd: 命令对象
+//   - meta: 元数据, 可以为nil
+//
+// 返回:
+//   - *Job: 任务对象
+//   - error: ID重复、管理器已关闭或启动失败时返回错误
+//
+// 注意:
+//   - 命令的标准输出和标准错误会被捕获, 同时保留通过WithStdout/WithStderr设置的输出
+//   - 启动进程时不持有管理器的锁, 启动期间的其他操作不会被阻塞
+func (m *JobManager) Start(id string, cmd *Command, meta map[string]string) (*Job, error) {
+	id, err := m.reserve(id)
+	if err != nil {
+		return nil, err
+	}
+	defer m.starting.Done()
+
+	ctx, cancel := context.WithCancel(m.ctx)
+	j := &Job{id: id, cmd: cmd, cancel: cancel, done: make(chan struct{})}
+	cmd.captureOutput(&j.stdout, &j.stderr)
+	cmd.parentCtx = ctx
+
+	start := time.Now()
+	if err := cmd.ExecAsync(); err != nil {
+		cancel()
+		m.mu.Lock()
+		delete(m.pending, id)
+		m.mu.Unlock()
+		return nil, err
+	}
+
+	j.info = JobInfo{
+		ID:       id,
+		Cmd:      cmd.CmdStr(),
+		Meta:     meta,
+		Status:   JobRunning,
+		PID:      cmd.GetPID(),
+		Start:    start,
+		ExitCode: -1,
+	}
+
+	m.mu.Lock()
+	delete(m.pending, id)
+	m.jobs[id] = j
+	m.order = append(m.order, j)
+	m.mu.Unlock()
+
+	go j.monitor()
+	return j, nil
+}
+
+// reserve 预留任务ID, 成功时增加正在启动的任务计数
+//
+// 参数:
+//   - id: 任务ID, 为空时自动生成
+//
+// 返回:
+//   - string: 预留的任务ID
+//   - error: ID重复或管理器已关闭时返回错误
+func (m *JobManager) reserve(id string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return "", ErrManagerClosed
+	}
+	m.prune()
+
+	if id == "" {
+		m.seq++
+		id = fmt.Sprintf("job-%d", m.seq)
+	}
+	if _, ok := m.jobs[id]; ok {
+		return "", fmt.Errorf("%w: %q", ErrJobExists, id)
+	}
+	if _, ok := m.pending[id]; ok {
+		return "", fmt.Errorf("%w: %q", ErrJobExists, id)
+	}
+
+	m.pending[id] = struct{}{}
+	m.starting.Add(1)
+	return id, nil
+}
+
+// Get 按ID获取任务
+//
+// 参数:
+//   - id: 任务ID
+//
+// 返回:
+//   - *Job: 任务对象
+//   - bool: 是否存在
+func (m *JobManager) Get(id string) (*Job, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.prune()
+	j, ok := m.jobs[id]
+	return j, ok
+}
+
+// List 获取所有任务的信息
+//
+// 返回:
+//   - []JobInfo: 按登记顺序排列的任务信息
+func (m *JobManager) List() []JobInfo {
+	m.mu.Lock()
+	m.prune()
+	jobs := append([]*Job(nil), m.order...)
+	m.mu.Unlock()
+
+	infos := make([]JobInfo, len(jobs))
+	for i, j := range jobs {
+		infos[i] = j.Info()
+	}
+	return infos
+}
+
+// Kill 终止任务
+//
+// 参数:
+//   - id: 任务ID
+//
+// 返回:
+//   - error: 任务不存在时返回 ErrJobNotFound
+//
+// 注意:
+//   - 按命令的终止策略终止, 不等待任务结束, 需要时调用 Wait
+//   - 任务已结束时不做任何操作
+func (m *JobManager) Kill(id string) error {
+	j, ok := m.Get(id)
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrJobNotFound, id)
+	}
+
+	j.kill()
+	return nil
+}
+
+// Wait 等待任务结束
+//
+// 参数:
+//   - id: 任务ID
+//
+// 返回:
+//   - JobInfo: 任务结束后的信息
+//   - error: 任务不存在时返回 ErrJobNotFound, 否则为任务的执行错误
+func (m *JobManager) Wait(id string) (JobInfo, error) {
+	j, ok := m.Get(id)
+	if !ok {
+		return JobInfo{}, fmt.Errorf("%w: %q", ErrJobNotFound, id)
+	}
+
+	<-j.done
+	info := j.Info()
+	return info, info.Err
+}
+
+// Shutdown 关闭管理器并停止所有正在运行的任务
+//
+// 参数:
+//   - ctx: 等待任务结束的上下文, 取消或超时后强制杀死剩余任务, 为nil时一直等待
+//
+// 返回:
+//   - error: 等待超时时返回上下文错误
+//
+// 注意:
+//   - 首先向任务发送终止策略中的信号 (未设置时为SIGTERM), ctx 结束后再按终止策略强制杀死整个进程组或进程树
+//   - 关闭后不能再启动新任务, 已结束任务的信息和输出仍可获取
+//
+// 示例:
+//
+//	sigs := make(chan os.Signal, 1)
+//	signal.Notify(sigs, syscall.SIGTERM)
+//	<-sigs
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	_ = jobs.Shutdown(ctx)
+func (m *JobManager) Shutdown(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	m.mu.Lock()
+	m.closed = true
+	m.mu.Unlock()
+
+	// 等待正在启动的任务登记完成
+	m.starting.Wait()
+
+	m.mu.Lock()
+	jobs := append([]*Job(nil), m.order...)
+	m.mu.Unlock()
+
+	for _, j := range jobs {
+		j.stop()
+	}
+
+	for _, j := range jobs {
+		select {
+		case <-j.done:
+		case <-ctx.Done():
+			// 超时后强制杀死剩余任务
+			for _, j := range jobs {
+				j.forceKill()
+			}
+			m.cancel()
+			return ctx.Err()
+		}
+	}
+	m.cancel()
+	return nil
+}
+
+// prune 清理超过保留期的已结束任务
+//
+// 注意:
+//   - 调用方需要持有 m.mu
+func (m *JobManager) prune() {
+	if m.retention <= 0 {
+		return
+	}
+
+	now := time.Now()
+	kept := m.order[:0]
+	for _, j := range m.order {
+		info := j.Info()
+		if info.Status != JobRunning && now.Sub(info.End) > m.retention {
+			delete(m.jobs, j.id)
+			continue
+		}
+		kept = append(kept, j)
+	}
+	clear(m.order[len(kept):])
+	m.order = kept
+}
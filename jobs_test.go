@@ -0,0 +1,165 @@
+package shellx
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestJobManager 测试任务登记、查询和等待
+func TestJobManager(t *testing.T) {
+	m := NewJobManager()
+
+	j, err := m.Start("build", NewCmdStr("echo built; echo warn >&2"), map[string]string{"owner": "ci"})
+	if err != nil {
+		t.Fatalf("启动任务失败: %v", err)
+	}
+	if _, err := m.Start("build", NewCmd("echo"), nil); !errors.Is(err, ErrJobExists) {
+		t.Errorf("ID重复时期望 ErrJobExists, 实际为: %v", err)
+	}
+
+	info, err := m.Wait("build")
+	if err != nil {
+		t.Fatalf("期望任务成功, 实际错误: %v", err)
+	}
+	if info.Status != JobSucceeded || info.ExitCode != 0 || info.PID == 0 || info.Meta["owner"] != "ci" {
+		t.Errorf("任务信息不符合预期: %+v", info)
+	}
+	if string(j.Stdout()) != "built\n" || string(j.Stderr()) != "warn\n" {
+		t.Errorf("捕获的输出不符合预期: %q, %q", j.Stdout(), j.Stderr())
+	}
+
+	auto, err := m.Start("", NewCmdStr("exit 3"), nil)
+	if err != nil {
+		t.Fatalf("启动任务失败: %v", err)
+	}
+	if auto.ID() != "job-1" {
+		t.Errorf("期望自动生成ID job-1, 实际为 %s", auto.ID())
+	}
+	if info, _ := m.Wait(auto.ID()); info.Status != JobFailed || info.ExitCode != 3 {
+		t.Errorf("任务信息不符合预期: %+v", info)
+	}
+
+	list := m.List()
+	if len(list) != 2 || list[0].ID != "build" || list[1].ID != "job-1" {
+		t.Errorf("任务列表不符合预期: %+v", list)
+	}
+
+	if _, err := m.Wait("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("期望 ErrJobNotFound, 实际为: %v", err)
+	}
+}
+
+// TestJobManagerKill 测试终止任务
+func TestJobManagerKill(t *testing.T) {
+	m := NewJobManager()
+	if _, err := m.Start("sleep", NewCmd("sleep", "5").WithShell(ShellNone), nil); err != nil {
+		t.Fatalf("启动任务失败: %v", err)
+	}
+
+	if err := m.Kill("sleep"); err != nil {
+		t.Fatalf("终止任务失败: %v", err)
+	}
+	info, err := m.Wait("sleep")
+	if err == nil || info.Status != JobKilled {
+		t.Errorf("期望任务被终止, 实际状态 %s, 错误: %v", info.Status, err)
+	}
+}
+
+// TestJobManagerShutdown 测试关闭管理器停止所有任务
+func TestJobManagerShutdown(t *testing.T) {
+	m := NewJobManager()
+	for _, id := range []string{"a", "b"} {
+		if _, err := m.Start(id, NewCmd("sleep", "5").WithShell(ShellNone), nil); err != nil {
+			t.Fatalf("启动任务失败: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("关闭失败: %v", err)
+	}
+
+	for _, info := range m.List() {
+		if info.Status != JobKilled {
+			t.Errorf("任务 %s 期望被终止, 实际为 %s", info.ID, info.Status)
+		}
+	}
+	if _, err := m.Start("c", NewCmd("echo"), nil); !errors.Is(err, ErrManagerClosed) {
+		t.Errorf("关闭后期望 ErrManagerClosed, 实际为: %v", err)
+	}
+}
+
+// TestJobManagerShutdownGraceful 测试关闭时先发送终止信号, 超时后强制杀死
+func TestJobManagerShutdownGraceful(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用POSIX信号")
+	}
+
+	t.Run("优雅退出", func(t *testing.T) {
+		m := NewJobManager()
+		j, err := m.Start("trap", NewCmdStr(`trap 'echo term; kill $!; exit 0' TERM; sleep 5 & wait`).WithShell(ShellSh), nil)
+		if err != nil {
+			t.Fatalf("启动任务失败: %v", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+		defer cancel()
+		if err := m.Shutdown(ctx); err != nil {
+			t.Fatalf("关闭失败: %v", err)
+		}
+		if got := strings.TrimSpace(string(j.Stdout())); got != "term" {
+			t.Errorf("期望任务收到SIGTERM后退出, 输出为 %q", got)
+		}
+	})
+
+	t.Run("超时后强制杀死进程组", func(t *testing.T) {
+		m := NewJobManager()
+		cmd := NewCmdStr(`trap '' TERM; sleep 5 & wait`).WithShell(ShellSh).WithKillStrategy(KillStrategy{Group: true})
+		j, err := m.Start("ignore", cmd, nil)
+		if err != nil {
+			t.Fatalf("启动任务失败: %v", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		defer cancel()
+		if err := m.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("期望超时错误, 实际为: %v", err)
+		}
+
+		select {
+		case <-j.Done():
+		case <-time.After(3 * time.Second):
+			t.Fatal("强制杀死后任务应结束")
+		}
+		if info := j.Info(); info.Status != JobKilled {
+			t.Errorf("期望任务被终止, 实际为 %s", info.Status)
+		}
+	})
+}
+
+// TestJobManagerRetention 测试结束任务的保留期
+func TestJobManagerRetention(t *testing.T) {
+	m := NewJobManager().SetRetention(50 * time.Millisecond)
+	if _, err := m.Start("done", NewCmd("echo", "x"), nil); err != nil {
+		t.Fatalf("启动任务失败: %v", err)
+	}
+	if _, err := m.Wait("done"); err != nil {
+		t.Fatalf("任务执行失败: %v", err)
+	}
+
+	if j, ok := m.Get("done"); !ok || strings.TrimSpace(string(j.Stdout())) != "x" {
+		t.Error("保留期内应能获取任务输出")
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := m.Get("done"); ok {
+		t.Error("超过保留期的任务应被清理")
+	}
+}
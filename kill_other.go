@@ -0,0 +1,21 @@
+//go:build !unix
+
+package shellx
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup 非Unix系统不支持进程组, 不做任何操作
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signalProcess 向进程发送信号, 非Unix系统忽略group参数
+func signalProcess(p *os.Process, sig os.Signal, group bool) error {
+	return p.Signal(sig)
+}
+
+// killProcess 杀死进程, 非Unix系统忽略group参数
+func killProcess(p *os.Process, group bool) error {
+	return p.Kill()
+}
@@ -0,0 +1,65 @@
+//go:build unix
+
+package shellx
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup 设置命令以新的进程组启动
+//
+// 参数:
+//   - cmd: exec.Cmd对象
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// signalProcess 向进程或进程组发送信号
+//
+// 参数:
+//   - p: 进程对象
+//   - sig: 信号
+//   - group: 是否发送给整个进程组
+//
+// 返回:
+//   - error: 发送错误, 进程已结束时返回os.ErrProcessDone
+func signalProcess(p *os.Process, sig os.Signal, group bool) error {
+	if !group {
+		return p.Signal(sig)
+	}
+
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return errors.New("unsupported signal type")
+	}
+
+	// 负数PID表示向进程组发送信号
+	if err := syscall.Kill(-p.Pid, s); err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+		return err
+	}
+	return nil
+}
+
+// killProcess 杀死进程或进程组
+//
+// 参数:
+//   - p: 进程对象
+//   - group: 是否杀死整个进程组
+//
+// 返回:
+//   - error: 错误信息
+func killProcess(p *os.Process, group bool) error {
+	if !group {
+		return p.Kill()
+	}
+	return signalProcess(p, syscall.SIGKILL, true)
+}
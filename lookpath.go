@@ -0,0 +1,267 @@
+// Package shellx 命令查找模块
+// 本文件在 FindCmd 的基础上提供了更灵活的命令查找功能，包括：
+//   - FindAll: 按 PATH 顺序返回所有匹配的可执行文件，用于检测同名命令的遮蔽
+//   - FindIn/FindAllIn: 在调用方提供的 PATH 中查找，例如命令自身环境变量中的 PATH
+//   - SetFindCache/ClearFindCache: 可选的查找结果缓存，PATH 或目录修改时间变化时自动失效
+//
+// 不通过 shell 执行的 Command 会使用自身环境变量中的 PATH 解析可执行文件，而不是父进程的 PATH。
+package shellx
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// findCache 命令查找结果缓存
+var findCache struct {
+	enabled atomic.Bool
+	mu      sync.Mutex
+	entries map[findKey]*findEntry
+}
+
+// findKey 缓存键, PATH不同的查找互不影响
+type findKey struct {
+	name     string // 命令名称
+	pathList string // PATH列表
+}
+
+// findEntry 缓存项
+type findEntry struct {
+	paths  []string             // 查找结果
+	mtimes map[string]time.Time // 查找时各目录的修改时间, 不存在的目录为零值
+}
+
+// SetFindCache 启用或关闭命令查找缓存
+//
+// 参数:
+//   - enabled: 是否启用
+//
+// 注意:
+//   - 缓存作用于 FindAll、FindIn、FindAllIn 以及 Command 使用自身 PATH 解析可执行文件
+//   - PATH 变化时使用新的缓存项, PATH 中任一目录的修改时间变化时缓存项失效
+//   - 替换目录中已有文件的内容不会改变目录的修改时间, 这种情况需要调用 ClearFindCache
+//   - 关闭缓存时会清空已缓存的结果
+func SetFindCache(enabled bool) {
+	findCache.enabled.Store(enabled)
+	if !enabled {
+		ClearFindCache()
+	}
+}
+
+// ClearFindCache 清空命令查找缓存
+func ClearFindCache() {
+	findCache.mu.Lock()
+	defer findCache.mu.Unlock()
+	findCache.entries = nil
+}
+
+// FindAll 在当前进程的 PATH 中查找命令的所有匹配
+//
+// 参数:
+//   - name: 命令名称
+//
+// 返回:
+//   - []string: 按 PATH 顺序排列的绝对路径, 第一个即为 FindCmd 的结果
+//   - error: 没有找到时返回包装了exec.ErrNotFound的*exec.Error
+//
+// 示例:
+//
+//	paths, _ := shellx.FindAll("python3")
+//	if len(paths) > 1 {
+//		fmt.Printf("%s 遮蔽了 %v\n", paths[0], paths[1:])
+//	}
+func FindAll(name string) ([]string, error) {
+	return FindAllIn(name, os.Getenv("PATH"))
+}
+
+// FindIn 在指定的 PATH 中查找命令
+//
+// 参数:
+//   - name: 命令名称
+//   - pathList: PATH 列表, 使用 os.PathListSeparator 分隔
+//
+// 返回:
+//   - string: 第一个匹配的绝对路径
+//   - error: 没有找到时返回包装了exec.ErrNotFound的*exec.Error
+//
+// 注意:
+//   - 名称包含路径分隔符时不搜索 PATH, 直接检查该路径
+//   - 出于安全考虑忽略 PATH 中的相对目录 (包括空目录项), 与 exec.LookPath 的 ErrDot 限制一致
+func FindIn(name, pathList string) (string, error) {
+	paths, err := FindAllIn(name, pathList)
+	if err != nil {
+		return "", err
+	}
+	return paths[0], nil
+}
+
+// FindAllIn 在指定的 PATH 中查找命令的所有匹配
+//
+// 参数:
+//   - name: 命令名称
+//   - pathList: PATH 列表, 使用 os.PathListSeparator 分隔
+//
+// 返回:
+//   - []string: 按 PATH 顺序排列的绝对路径, 同一文件只出现一次
+//   - error: 没有找到时返回包装了exec.ErrNotFound的*exec.Error
+func FindAllIn(name, pathList string) ([]string, error) {
+	if name == "" {
+		return nil, &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+
+	// 包含路径分隔符时直接检查该路径
+	if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		path, err := FindCmd(name)
+		if err != nil {
+			return nil, err
+		}
+		return []string{path}, nil
+	}
+
+	if !findCache.enabled.Load() {
+		return findAll(name, pathList)
+	}
+
+	key := findKey{name: name, pathList: pathList}
+	findCache.mu.Lock()
+	entry := findCache.entries[key]
+	findCache.mu.Unlock()
+	if entry != nil && entry.valid() {
+		return append([]string(nil), entry.paths...), nil
+	}
+
+	// 先记录目录修改时间再查找, 查找期间目录发生变化时下次查找会重新扫描
+	mtimes := dirMtimes(pathList)
+	paths, err := findAll(name, pathList)
+	if err != nil {
+		return nil, err
+	}
+
+	findCache.mu.Lock()
+	if findCache.entries == nil {
+		findCache.entries = make(map[findKey]*findEntry)
+	}
+	findCache.entries[key] = &findEntry{paths: paths, mtimes: mtimes}
+	findCache.mu.Unlock()
+	return append([]string(nil), paths...), nil
+}
+
+// findAll 按 PATH 顺序查找命令的所有匹配
+func findAll(name, pathList string) ([]string, error) {
+	var paths []string
+	seen := make(map[string]bool)
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" || !filepath.IsAbs(dir) {
+			continue
+		}
+
+		// 名称包含路径分隔符时 exec.LookPath 只检查该文件, 并在 Windows 上处理 PATHEXT
+		path, err := exec.LookPath(filepath.Join(dir, name))
+		if err != nil || seen[path] {
+			continue
+		}
+		seen[path] = true
+		paths = append(paths, path)
+	}
+
+	if len(paths) == 0 {
+		return nil, &exec.Error{Name: name, Err: exec.ErrNotFound}
+	}
+	return paths, nil
+}
+
+// dirMtimes 获取 PATH 中各目录的修改时间
+func dirMtimes(pathList string) map[string]time.Time {
+	mtimes := make(map[string]time.Time)
+	for _, dir := range filepath.SplitList(pathList) {
+		if dir == "" || !filepath.IsAbs(dir) {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil {
+			mtimes[dir] = info.ModTime()
+		} else {
+			mtimes[dir] = time.Time{}
+		}
+	}
+	return mtimes
+}
+
+// valid 检查缓存项记录的目录修改时间是否仍然有效
+func (e *findEntry) valid() bool {
+	for dir, mtime := range e.mtimes {
+		info, err := os.Stat(dir)
+		switch {
+		case err != nil:
+			if !mtime.IsZero() {
+				return false
+			}
+		case !info.ModTime().Equal(mtime):
+			return false
+		}
+	}
+	return true
+}
+
+// envPath 获取环境变量列表中的 PATH
+//
+// 参数:
+//   - envs: "key=value" 形式的环境变量列表
+//
+// 返回:
+//   - string: PATH 的值, 存在多个时以最后一个为准 (与 os/exec 一致)
+//   - bool: 是否存在
+func envPath(envs []string) (string, bool) {
+	for i := len(envs) - 1; i >= 0; i-- {
+		key, value, ok := strings.Cut(envs[i], "=")
+		if !ok {
+			continue
+		}
+		// Windows 环境变量名不区分大小写
+		if key == "PATH" || (runtime.GOOS == "windows" && strings.EqualFold(key, "PATH")) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// resolveExecPath 使用命令自身环境变量中的 PATH 解析可执行文件
+//
+// 参数:
+//   - cmd: exec.Cmd对象, Args[0]为要解析的命令名称
+//   - envs: 命令的环境变量
+//
+// 注意:
+//   - 环境变量中没有 PATH、PATH 与父进程相同或命令名包含路径分隔符时保留 os/exec 的解析结果
+//   - 解析失败时设置 cmd.Err, 启动时返回命令未找到错误
+func resolveExecPath(cmd *exec.Cmd, envs []string) {
+	name := cmd.Args[0]
+	if strings.ContainsRune(name, '/') || strings.ContainsRune(name, filepath.Separator) {
+		return
+	}
+
+	// PATH与父进程相同时os/exec已经完成了解析
+	pathList, ok := envPath(envs)
+	if !ok || pathList == os.Getenv("PATH") {
+		return
+	}
+
+	path, err := FindIn(name, pathList)
+	if err != nil {
+		var execErr *exec.Error
+		if errors.As(err, &execErr) {
+			cmd.Err = execErr
+		} else {
+			cmd.Err = &exec.Error{Name: name, Err: err}
+		}
+		return
+	}
+	cmd.Path = path
+	cmd.Err = nil
+}
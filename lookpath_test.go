@@ -0,0 +1,148 @@
+package shellx
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeExecutable 在目录中创建可执行脚本
+func writeExecutable(t *testing.T, dir, name, output string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	script := "#!/bin/sh\necho " + output + "\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("创建可执行文件失败: %v", err)
+	}
+	return path
+}
+
+// TestFindAll 测试查找命令的所有匹配
+func TestFindAll(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用shell脚本作为可执行文件")
+	}
+
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	p1 := writeExecutable(t, dir1, "shellx-find", "one")
+	p2 := writeExecutable(t, dir2, "shellx-find", "two")
+	pathList := strings.Join([]string{dir1, "relative", "", dir2, dir1}, string(os.PathListSeparator))
+
+	t.Run("按PATH顺序返回所有匹配", func(t *testing.T) {
+		paths, err := FindAllIn("shellx-find", pathList)
+		if err != nil {
+			t.Fatalf("查找失败: %v", err)
+		}
+		if !slices.Equal(paths, []string{p1, p2}) {
+			t.Fatalf("期望 %v, 实际为 %v", []string{p1, p2}, paths)
+		}
+
+		path, err := FindIn("shellx-find", pathList)
+		if err != nil || path != p1 {
+			t.Fatalf("期望 %s, 实际为 %s (%v)", p1, path, err)
+		}
+	})
+
+	t.Run("忽略不可执行文件", func(t *testing.T) {
+		if err := os.WriteFile(filepath.Join(dir1, "shellx-plain"), []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := FindIn("shellx-plain", pathList); !errors.Is(err, exec.ErrNotFound) {
+			t.Fatalf("期望 exec.ErrNotFound, 实际为 %v", err)
+		}
+	})
+
+	t.Run("当前进程PATH", func(t *testing.T) {
+		paths, err := FindAll("sh")
+		if err != nil {
+			t.Fatalf("查找失败: %v", err)
+		}
+		if path, _ := FindCmd("sh"); path != paths[0] {
+			t.Fatalf("期望第一个匹配与FindCmd相同: %s, 实际为 %v", path, paths)
+		}
+	})
+}
+
+// TestFindCache 测试命令查找缓存
+func TestFindCache(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用shell脚本作为可执行文件")
+	}
+
+	SetFindCache(true)
+	t.Cleanup(func() { SetFindCache(false) })
+
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	p2 := writeExecutable(t, dir2, "shellx-cached", "two")
+	pathList := dir1 + string(os.PathListSeparator) + dir2
+
+	paths, err := FindAllIn("shellx-cached", pathList)
+	if err != nil || !slices.Equal(paths, []string{p2}) {
+		t.Fatalf("期望 [%s], 实际为 %v (%v)", p2, paths, err)
+	}
+
+	// 恢复目录修改时间后缓存仍然有效, 返回旧结果
+	info, err := os.Stat(dir1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p1 := writeExecutable(t, dir1, "shellx-cached", "one")
+	if err := os.Chtimes(dir1, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if paths, _ := FindAllIn("shellx-cached", pathList); !slices.Equal(paths, []string{p2}) {
+		t.Fatalf("期望使用缓存结果 [%s], 实际为 %v", p2, paths)
+	}
+
+	// 目录修改时间变化后缓存失效
+	mtime := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(dir1, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err = FindAllIn("shellx-cached", pathList)
+	if err != nil || !slices.Equal(paths, []string{p1, p2}) {
+		t.Fatalf("目录修改后期望 [%s %s], 实际为 %v (%v)", p1, p2, paths, err)
+	}
+
+	// 修改返回结果不影响缓存
+	paths[0] = "changed"
+	if path, _ := FindIn("shellx-cached", pathList); path != p1 {
+		t.Fatalf("期望 %s, 实际为 %s", p1, path)
+	}
+}
+
+// TestCommandEnvPath 测试命令使用自身环境变量中的PATH解析可执行文件
+func TestCommandEnvPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用shell脚本作为可执行文件")
+	}
+
+	dir := t.TempDir()
+	writeExecutable(t, dir, "shellx-envpath", "from-env-path")
+
+	t.Run("使用命令的PATH", func(t *testing.T) {
+		out, err := NewCmd("shellx-envpath").WithShell(ShellNone).
+			WithEnv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH")).ExecOutput()
+		if err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		if strings.TrimSpace(string(out)) != "from-env-path" {
+			t.Fatalf("期望输出 from-env-path, 实际为 %q", out)
+		}
+	})
+
+	t.Run("命令的PATH中不存在", func(t *testing.T) {
+		err := NewCmd("sh", "-c", "true").WithShell(ShellNone).WithEnv("PATH", dir).Exec()
+		if err == nil || !strings.Contains(err.Error(), "command not found") {
+			t.Fatalf("期望命令未找到错误, 实际为 %v", err)
+		}
+	})
+}
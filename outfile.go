@@ -0,0 +1,304 @@
+// Package shellx 输出文件模块
+// 本文件实现了 WithStdoutFile、WithStderrFile 和 WithCombinedFile 的文件输出，包括：
+//   - FileOptions/RotateOptions: 文件打开方式和轮转策略
+//   - rotatingFile: 按大小或时间轮转的文件写入器，保留指定数量的备份并可使用gzip压缩
+//   - openOutputFiles/closeOutputFiles: 在命令启动时打开文件，在命令结束时关闭文件
+//
+// 文件输出与 WithStdout/WithStderr 设置的输出以及内存捕获同时生效。
+package shellx
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultFilePerm 输出文件的默认权限
+const defaultFilePerm os.FileMode = 0o644
+
+// FileOptions 输出文件配置
+type FileOptions struct {
+	Append bool          // 是否追加写入, 为false时截断已有文件
+	Perm   os.FileMode   // 新建文件的权限, 为0时使用0644
+	Rotate RotateOptions // 轮转策略, 零值表示不轮转
+}
+
+// RotateOptions 输出文件轮转策略
+//
+// 轮转时当前文件重命名为 path.1, 已有备份依次后移 (path.1 -> path.2 ...),
+// 超过 MaxBackups 的最旧备份会被删除。
+type RotateOptions struct {
+	MaxSize    int64         // 单个文件的最大字节数, 小于等于0表示不按大小轮转
+	MaxAge     time.Duration // 单个文件的最长写入时间, 小于等于0表示不按时间轮转
+	MaxBackups int           // 保留的备份数量, 小于等于0时保留1个
+	Compress   bool          // 是否使用gzip压缩备份 (备份文件名为 path.N.gz)
+}
+
+// outputFile 命令的输出文件配置
+type outputFile struct {
+	path   string      // 文件路径
+	opts   FileOptions // 文件配置
+	stdout bool        // 是否写入标准输出
+	stderr bool        // 是否写入标准错误
+}
+
+// rotatingFile 支持轮转的文件写入器
+//
+// 注意:
+//   - 并发安全, 合并输出时标准输出和标准错误共用同一个写入器
+type rotatingFile struct {
+	mu     sync.Mutex
+	path   string      // 文件路径
+	opts   FileOptions // 文件配置
+	f      *os.File    // 当前文件 (轮转失败且无法重新打开时为nil)
+	size   int64       // 当前文件大小
+	base   int64       // 按大小轮转的起点 (轮转失败后推迟到下一个阈值再重试)
+	opened time.Time   // 当前文件的打开时间
+	closed bool        // 是否已关闭
+}
+
+// openRotatingFile 打开输出文件
+//
+// 参数:
+//   - path: 文件路径
+//   - opts: 文件配置
+//
+// 返回:
+//   - *rotatingFile: 文件写入器
+//   - error: 打开文件失败时返回错误
+func openRotatingFile(path string, opts FileOptions) (*rotatingFile, error) {
+	if opts.Perm == 0 {
+		opts.Perm = defaultFilePerm
+	}
+
+	r := &rotatingFile{path: path, opts: opts}
+	flag := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if opts.Append {
+		flag = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	if err := r.open(flag); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open 以指定方式打开当前文件
+func (r *rotatingFile) open(flag int) error {
+	f, err := os.OpenFile(r.path, flag, r.opts.Perm)
+	if err != nil {
+		return err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	r.f, r.size, r.base, r.opened = f, info.Size(), 0, time.Now()
+	return nil
+}
+
+// Write 写入数据, 需要时先轮转文件
+//
+// 注意:
+//   - 轮转失败时继续追加写入当前文件, 再写入MaxSize字节或经过MaxAge后重试轮转
+//   - 当前文件无法重新打开时返回错误, 下一次写入时重试打开
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, os.ErrClosed
+	}
+
+	failed := false
+	if r.f != nil && r.shouldRotate(len(p)) {
+		failed = r.rotate() != nil
+	}
+	if r.f == nil {
+		if err := r.open(os.O_CREATE | os.O_WRONLY | os.O_APPEND); err != nil {
+			return 0, err
+		}
+		if failed {
+			r.base = r.size
+		}
+	}
+
+	n, err := r.f.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// shouldRotate 判断写入n字节前是否需要轮转
+func (r *rotatingFile) shouldRotate(n int) bool {
+	rot := r.opts.Rotate
+	if r.size == 0 {
+		return false
+	}
+	if rot.MaxSize > 0 && r.size-r.base+int64(n) > rot.MaxSize {
+		return true
+	}
+	return rot.MaxAge > 0 && time.Since(r.opened) >= rot.MaxAge
+}
+
+// rotate 关闭当前文件, 后移备份并重新打开空文件
+//
+// 注意:
+//   - 先将当前文件移到临时位置并完成压缩, 再后移备份, 失败时不会删除已有的备份
+//   - 失败时尽量将当前文件移回原位置并保持关闭, 由 Write 重新以追加方式打开
+func (r *rotatingFile) rotate() error {
+	if err := r.f.Close(); err != nil {
+		return err
+	}
+	r.f = nil
+
+	keep := max(r.opts.Rotate.MaxBackups, 1)
+	ext := ""
+	if r.opts.Rotate.Compress {
+		ext = ".gz"
+	}
+	backup := func(i int) string {
+		return fmt.Sprintf("%s.%d%s", r.path, i, ext)
+	}
+
+	// 上一次失败残留的临时文件无法移回时不再覆盖, 避免丢失其中的内容
+	tmp := r.path + ".rotating"
+	if _, err := os.Lstat(tmp); err == nil {
+		return fmt.Errorf("rotate %s: %s already exists", r.path, tmp)
+	}
+	if err := os.Rename(r.path, tmp); err != nil {
+		return err
+	}
+
+	staged := tmp
+	if r.opts.Rotate.Compress {
+		staged = tmp + ext
+		if err := compressFile(tmp, staged, r.opts.Perm); err != nil {
+			_ = os.Remove(staged)
+			_ = os.Rename(tmp, r.path)
+			return err
+		}
+	}
+
+	// 依次后移备份, 最旧的备份被覆盖
+	for i := keep - 1; i >= 1; i-- {
+		if err := os.Rename(backup(i), backup(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			r.unstage(tmp, staged)
+			return err
+		}
+	}
+	if err := os.Rename(staged, backup(1)); err != nil {
+		r.unstage(tmp, staged)
+		return err
+	}
+	if staged != tmp {
+		_ = os.Remove(tmp)
+	}
+
+	return r.open(os.O_CREATE | os.O_WRONLY | os.O_TRUNC)
+}
+
+// unstage 轮转失败时将临时文件移回当前文件的位置
+func (r *rotatingFile) unstage(tmp, staged string) {
+	if staged != tmp {
+		_ = os.Remove(staged)
+	}
+	_ = os.Rename(tmp, r.path)
+}
+
+// Close 刷新并关闭当前文件
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	if r.f == nil {
+		return nil
+	}
+
+	err := r.f.Sync()
+	if cerr := r.f.Close(); err == nil {
+		err = cerr
+	}
+	r.f = nil
+	return err
+}
+
+// compressFile 将文件压缩为gzip格式, 源文件保持不变
+//
+// 参数:
+//   - src: 源文件路径
+//   - dst: 压缩文件路径
+//   - perm: 压缩文件权限
+//
+// 返回:
+//   - error: 压缩失败时返回错误
+func compressFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+
+	zw := gzip.NewWriter(out)
+	if _, err := io.Copy(zw, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// openOutputFiles 打开输出文件并与现有输出合并
+//
+// 返回:
+//   - error: 打开文件失败时返回错误, 已打开的文件会被关闭
+func (c *Command) openOutputFiles() error {
+	if len(c.outputFiles) == 0 {
+		return nil
+	}
+
+	// 标准输出和标准错误共用同一个输出时, 分别合并后会由两个协程并发写入
+	if c.stdout != nil && c.stdout == c.stderr {
+		shared := &lockedWriter{w: c.stdout}
+		c.stdout, c.stderr = shared, shared
+	}
+
+	for _, of := range c.outputFiles {
+		f, err := openRotatingFile(of.path, of.opts)
+		if err != nil {
+			c.closeOutputFiles()
+			return err
+		}
+		c.openFiles = append(c.openFiles, f)
+
+		if of.stdout {
+			c.stdout = teeWriter(c.stdout, f)
+		}
+		if of.stderr {
+			c.stderr = teeWriter(c.stderr, f)
+		}
+	}
+	return nil
+}
+
+// closeOutputFiles 刷新并关闭已打开的输出文件
+func (c *Command) closeOutputFiles() {
+	for _, f := range c.openFiles {
+		_ = f.Close()
+	}
+	c.openFiles = nil
+}
@@ -0,0 +1,217 @@
+package shellx
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWithOutputFiles 测试输出写入文件并与内存捕获同时生效
+func TestWithOutputFiles(t *testing.T) {
+	dir := t.TempDir()
+	outPath := filepath.Join(dir, "out.log")
+	errPath := filepath.Join(dir, "err.log")
+	allPath := filepath.Join(dir, "all.log")
+
+	if err := os.WriteFile(outPath, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stderr bytes.Buffer
+	out, err := NewCmdStr("echo hello; echo oops >&2").
+		WithStderr(&stderr).
+		WithStdoutFile(outPath, FileOptions{Append: true}).
+		WithStderrFile(errPath, FileOptions{}).
+		WithCombinedFile(allPath, FileOptions{}).
+		ExecStdout()
+	if err != nil {
+		t.Fatalf("期望执行成功, 实际错误: %v", err)
+	}
+
+	if string(out) != "hello\n" || stderr.String() != "oops\n" {
+		t.Errorf("内存捕获不符合预期, 标准输出: %q, 标准错误: %q", out, stderr.String())
+	}
+	if data, _ := os.ReadFile(outPath); string(data) != "old\nhello\n" {
+		t.Errorf("追加写入的标准输出文件内容不符合预期: %q", data)
+	}
+	if data, _ := os.ReadFile(errPath); string(data) != "oops\n" {
+		t.Errorf("标准错误文件内容不符合预期: %q", data)
+	}
+	if data, _ := os.ReadFile(allPath); !strings.Contains(string(data), "hello\n") || !strings.Contains(string(data), "oops\n") {
+		t.Errorf("合并文件内容不符合预期: %q", data)
+	}
+}
+
+// TestWithOutputFileOpenError 测试文件打开失败时不启动命令
+func TestWithOutputFileOpenError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing", "out.log")
+	err := NewCmd("echo", "x").WithStdoutFile(path, FileOptions{}).Exec()
+	if err == nil {
+		t.Error("目录不存在时期望返回错误")
+	}
+}
+
+// TestRotatingFile 测试按大小轮转和压缩备份
+func TestRotatingFile(t *testing.T) {
+	t.Run("按大小轮转", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		f, err := openRotatingFile(path, FileOptions{Rotate: RotateOptions{MaxSize: 6, MaxBackups: 2}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n"} {
+			if _, err := f.Write([]byte(s)); err != nil {
+				t.Fatalf("写入失败: %v", err)
+			}
+		}
+		if err := f.Close(); err != nil {
+			t.Fatalf("关闭失败: %v", err)
+		}
+
+		want := map[string]string{"": "dddd\n", ".1": "cccc\n", ".2": "bbbb\n"}
+		for suffix, content := range want {
+			if data, _ := os.ReadFile(path + suffix); string(data) != content {
+				t.Errorf("文件 %s 内容期望为 %q, 实际为 %q", path+suffix, content, data)
+			}
+		}
+		if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+			t.Error("超过保留数量的备份应被删除")
+		}
+	})
+
+	t.Run("按时间轮转并压缩", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		f, err := openRotatingFile(path, FileOptions{Rotate: RotateOptions{MaxAge: 10 * time.Millisecond, Compress: true}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = f.Write([]byte("first\n"))
+		time.Sleep(20 * time.Millisecond)
+		_, _ = f.Write([]byte("second\n"))
+		_ = f.Close()
+
+		gz, err := os.Open(path + ".1.gz")
+		if err != nil {
+			t.Fatalf("期望生成压缩备份: %v", err)
+		}
+		defer gz.Close()
+		zr, err := gzip.NewReader(gz)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data, _ := io.ReadAll(zr); string(data) != "first\n" {
+			t.Errorf("压缩备份内容不符合预期: %q", data)
+		}
+		if data, _ := os.ReadFile(path); string(data) != "second\n" {
+			t.Errorf("当前文件内容不符合预期: %q", data)
+		}
+	})
+}
+
+// TestRotatingFileRotateError 测试轮转失败时继续写入当前文件并在之后重试轮转
+func TestRotatingFileRotateError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	f, err := openRotatingFile(path, FileOptions{Rotate: RotateOptions{MaxSize: 6, MaxBackups: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// 备份位置是非空目录时无法删除, 轮转失败
+	if err := os.MkdirAll(filepath.Join(path+".1", "keep"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"aaaa\n", "bbbb\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatalf("轮转失败时写入不应失败: %v", err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "aaaa\nbbbb\n" {
+		t.Errorf("轮转失败时应继续写入当前文件, 实际内容为 %q", data)
+	}
+
+	if err := os.RemoveAll(path + ".1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Write([]byte("cccc\n")); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+	if data, _ := os.ReadFile(path + ".1"); string(data) != "aaaa\nbbbb\n" {
+		t.Errorf("期望重试轮转成功, 备份内容为 %q", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "cccc\n" {
+		t.Errorf("当前文件内容不符合预期: %q", data)
+	}
+}
+
+// TestRotatingFileRotateErrorKeepsBackups 测试轮转失败时保留已有备份并推迟到下一个阈值重试
+func TestRotatingFileRotateErrorKeepsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	for i, s := range []string{"old1", "old2"} {
+		if err := os.WriteFile(fmt.Sprintf("%s.%d.gz", path, i+1), []byte(s), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := openRotatingFile(path, FileOptions{Rotate: RotateOptions{MaxSize: 12, MaxBackups: 2, Compress: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// 压缩目标是非空目录时无法创建, 轮转失败
+	blocker := path + ".rotating.gz"
+	if err := os.MkdirAll(filepath.Join(blocker, "keep"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{"aaaa\n", "bbbb\n", "cccc\n"} {
+		if _, err := f.Write([]byte(s)); err != nil {
+			t.Fatalf("轮转失败时写入不应失败: %v", err)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != "aaaa\nbbbb\ncccc\n" {
+		t.Errorf("轮转失败时应继续写入当前文件, 实际内容为 %q", data)
+	}
+	for i, s := range []string{"old1", "old2"} {
+		if data, _ := os.ReadFile(fmt.Sprintf("%s.%d.gz", path, i+1)); string(data) != s {
+			t.Errorf("轮转失败时不应改动备份 %d, 实际内容为 %q", i+1, data)
+		}
+	}
+
+	// 失败后再写入MaxSize字节才重试轮转
+	if err := os.RemoveAll(blocker); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte("dddd\n"))
+	if data, _ := os.ReadFile(path + ".1.gz"); string(data) != "old1" {
+		t.Errorf("未到下一个阈值时不应重试轮转, 备份内容为 %q", data)
+	}
+	_, _ = f.Write([]byte("eeee\n"))
+
+	gz, err := os.Open(path + ".1.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer gz.Close()
+	zr, err := gzip.NewReader(gz)
+	if err != nil {
+		t.Fatalf("期望重试轮转成功: %v", err)
+	}
+	if data, _ := io.ReadAll(zr); string(data) != "aaaa\nbbbb\ncccc\ndddd\n" {
+		t.Errorf("压缩备份内容不符合预期: %q", data)
+	}
+	if data, _ := os.ReadFile(path + ".2.gz"); string(data) != "old1" {
+		t.Errorf("期望旧备份后移, 实际内容为 %q", data)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "eeee\n" {
+		t.Errorf("当前文件内容不符合预期: %q", data)
+	}
+	if _, err := os.Stat(path + ".rotating"); !os.IsNotExist(err) {
+		t.Errorf("轮转成功后不应残留临时文件: %v", err)
+	}
+}
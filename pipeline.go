@@ -0,0 +1,377 @@
+// Package shellx 任务流水线模块
+// 本文件定义了 Pipeline 结构体，用于按依赖关系编排多个命令，支持：
+//   - 依赖声明：Node.DependsOn 声明节点之间的依赖关系
+//   - 拓扑执行：依赖满足后立即执行，SetLimit 限制最大并行数量
+//   - 失败传播：上游节点失败时跳过所有下游节点
+//   - 清理节点：Node.AlwaysRun 标记的节点在依赖结束后总会执行
+//   - 环检测：执行前检测依赖环和未知依赖
+//   - 执行报告：Run 返回包含每个节点状态和耗时的报告
+//
+// 节点的命令复用 Command 自身的超时、上下文和终止策略。
+package shellx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrUnknownDependency 表示节点依赖了不存在的节点
+var ErrUnknownDependency = errors.New("unknown pipeline dependency")
+
+// CycleError 表示流水线中存在依赖环
+type CycleError struct {
+	Path []string // 环上的节点, 首尾相同
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("pipeline dependency cycle: %s", strings.Join(e.Path, " -> "))
+}
+
+// NodeStatus 节点执行状态
+type NodeStatus int
+
+const (
+	NodePending   NodeStatus = iota // 等待执行
+	NodeSucceeded                   // 执行成功
+	NodeFailed                      // 执行失败
+	NodeSkipped                     // 因上游失败或流水线被取消而跳过
+)
+
+// String 返回节点状态的字符串表示
+func (s NodeStatus) String() string {
+	switch s {
+	case NodePending:
+		return "pending"
+
+	case NodeSucceeded:
+		return "succeeded"
+
+	case NodeFailed:
+		return "failed"
+
+	case NodeSkipped:
+		return "skipped"
+
+	default:
+		return "unknown"
+	}
+}
+
+// Node 流水线节点
+type Node struct {
+	name      string   // 节点名称
+	cmd       *Command // 节点命令
+	deps      []string // 依赖的节点名称
+	alwaysRun bool     // 是否总是执行
+}
+
+// DependsOn 声明节点依赖的其他节点
+//
+// 参数:
+//   - names: 依赖的节点名称
+//
+// 返回:
+//   - *Node: 节点对象 (支持链式调用)
+func (n *Node) DependsOn(names ...string) *Node {
+	n.deps = append(n.deps, names...)
+	return n
+}
+
+// AlwaysRun 标记节点在依赖结束后总会执行, 无论依赖是否成功
+//
+// 返回:
+//   - *Node: 节点对象 (支持链式调用)
+//
+// 注意:
+//   - 适用于清理类节点, 流水线上下文被取消时仍会执行, 但不会继承其取消状态
+func (n *Node) AlwaysRun() *Node {
+	n.alwaysRun = true
+	return n
+}
+
+// Name 获取节点名称
+func (n *Node) Name() string {
+	return n.name
+}
+
+// NodeReport 单个节点的执行报告
+type NodeReport struct {
+	Name   string     // 节点名称
+	Status NodeStatus // 执行状态
+	Result Result     // 执行结果, 跳过的节点只有Cmd和Err有效
+}
+
+// PipelineReport 流水线执行报告
+type PipelineReport struct {
+	Nodes    []NodeReport  // 按添加顺序排列的节点报告
+	Start    time.Time     // 开始时间
+	Duration time.Duration // 总耗时
+}
+
+// Node 按名称获取节点报告
+//
+// 参数:
+//   - name: 节点名称
+//
+// 返回:
+//   - NodeReport: 节点报告
+//   - bool: 是否存在
+func (r *PipelineReport) Node(name string) (NodeReport, bool) {
+	for _, n := range r.Nodes {
+		if n.Name == name {
+			return n, true
+		}
+	}
+	return NodeReport{}, false
+}
+
+// Pipeline 按依赖关系编排命令的流水线
+//
+// 注意:
+//   - 配置方法不是并发安全的, 不要在多个 goroutine 中并发配置
+//   - 每个流水线只能执行一次, 节点命令与 Command 一样只能执行一次
+type Pipeline struct {
+	nodes map[string]*Node // 节点索引
+	order []*Node          // 按添加顺序排列的节点
+	limit int              // 最大并行数量 (小于等于0表示不限制)
+}
+
+// NewPipeline 创建流水线
+//
+// 返回:
+//   - *Pipeline: 流水线对象
+func NewPipeline() *Pipeline {
+	return &Pipeline{nodes: make(map[string]*Node)}
+}
+
+// SetLimit 设置最大并行数量
+//
+// 参数:
+//   - n: 最大并行数量, 小于等于0表示不限制
+//
+// 返回:
+//   - *Pipeline: 流水线对象
+func (p *Pipeline) SetLimit(n int) *Pipeline {
+	p.limit = n
+	return p
+}
+
+// Add 添加节点
+//
+// 参数:
+//   - name: 节点名称, 在流水线中唯一
+//   - cmd: 节点命令
+//
+// 返回:
+//   - *Node: 节点对象, 可继续声明依赖
+//
+// 注意:
+//   - 名称为空、重复或命令为nil时会panic
+func (p *Pipeline) Add(name string, cmd *Command) *Node {
+	if name == "" {
+		panic("pipeline node name must not be empty")
+	}
+	if cmd == nil {
+		panic(fmt.Sprintf("pipeline node %q command must not be nil", name))
+	}
+	if _, ok := p.nodes[name]; ok {
+		panic(fmt.Sprintf("pipeline node %q already exists", name))
+	}
+
+	n := &Node{name: name, cmd: cmd}
+	p.nodes[name] = n
+	p.order = append(p.order, n)
+	return n
+}
+
+// Validate 检查未知依赖和依赖环
+//
+// 返回:
+//   - error: 未知依赖返回包装了 ErrUnknownDependency 的错误, 依赖环返回 *CycleError
+func (p *Pipeline) Validate() error {
+	for _, n := range p.order {
+		for _, dep := range n.deps {
+			if _, ok := p.nodes[dep]; !ok {
+				return fmt.Errorf("%w: %q depends on %q", ErrUnknownDependency, n.name, dep)
+			}
+		}
+	}
+
+	// 深度优先搜索检测环
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(p.order))
+	var stack []string
+
+	var visit func(n *Node) error
+	visit = func(n *Node) error {
+		state[n.name] = visiting
+		stack = append(stack, n.name)
+
+		for _, dep := range n.deps {
+			switch state[dep] {
+			case visiting:
+				// 从栈中截取环路径
+				for i, name := range stack {
+					if name == dep {
+						path := append([]string{}, stack[i:]...)
+						return &CycleError{Path: append(path, dep)}
+					}
+				}
+			case unvisited:
+				if err := visit(p.nodes[dep]); err != nil {
+					return err
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		state[n.name] = visited
+		return nil
+	}
+
+	for _, n := range p.order {
+		if state[n.name] == unvisited {
+			if err := visit(n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// nodeDone 节点完成事件
+type nodeDone struct {
+	node *Node
+	err  error
+}
+
+// Run 执行流水线
+//
+// 参数:
+//   - ctx: 上下文, 取消时终止正在运行的节点并跳过尚未执行的节点(AlwaysRun节点除外), 为nil时使用context.Background()
+//
+// 返回:
+//   - *PipelineReport: 执行报告, 校验失败时为nil
+//   - error: 校验错误, 或使用errors.Join聚合的节点错误
+func (p *Pipeline) Run(ctx context.Context) (*PipelineReport, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	report := &PipelineReport{Start: time.Now(), Nodes: make([]NodeReport, len(p.order))}
+	index := make(map[string]int, len(p.order))
+	for i, n := range p.order {
+		index[n.name] = i
+		report.Nodes[i] = NodeReport{Name: n.name, Result: Result{Cmd: n.cmd.CmdStr(), ExitCode: -1}}
+	}
+
+	// 构建反向依赖和剩余依赖计数
+	dependents := make(map[string][]*Node, len(p.order))
+	pending := make(map[string]int, len(p.order))
+	var ready []*Node
+	for _, n := range p.order {
+		pending[n.name] = len(n.deps)
+		for _, dep := range n.deps {
+			dependents[dep] = append(dependents[dep], n)
+		}
+		if len(n.deps) == 0 {
+			ready = append(ready, n)
+		}
+	}
+
+	done := make(chan nodeDone)
+	running, finished := 0, 0
+	var errs []error
+
+	// complete 标记节点结束并将依赖已全部结束的下游节点加入就绪队列
+	complete := func(n *Node) {
+		finished++
+		for _, d := range dependents[n.name] {
+			pending[d.name]--
+			if pending[d.name] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+
+	for finished < len(p.order) {
+		// 启动或跳过就绪的节点
+		for len(ready) > 0 {
+			n := ready[0]
+			nr := &report.Nodes[index[n.name]]
+
+			if reason := p.skipReason(ctx, n, report, index); reason != "" {
+				ready = ready[1:]
+				nr.Status = NodeSkipped
+				nr.Result.Err = fmt.Errorf("pipeline node %q skipped: %s", n.name, reason)
+				complete(n)
+				continue
+			}
+
+			if p.limit > 0 && running >= p.limit {
+				break
+			}
+			ready = ready[1:]
+
+			parent := ctx
+			if n.alwaysRun {
+				parent = context.WithoutCancel(ctx)
+			}
+
+			running++
+			go func() {
+				err := runResult(n.cmd, parent, &nr.Result)
+				done <- nodeDone{node: n, err: err}
+			}()
+		}
+
+		if finished == len(p.order) {
+			break
+		}
+
+		ev := <-done
+		running--
+		nr := &report.Nodes[index[ev.node.name]]
+		if ev.err != nil {
+			nr.Status = NodeFailed
+			errs = append(errs, fmt.Errorf("pipeline node %q: %w", ev.node.name, ev.err))
+		} else {
+			nr.Status = NodeSucceeded
+		}
+		complete(ev.node)
+	}
+
+	report.Duration = time.Since(report.Start)
+	return report, errors.Join(errs...)
+}
+
+// skipReason 判断就绪节点是否需要跳过
+//
+// 返回:
+//   - string: 跳过原因, 为空表示需要执行
+func (p *Pipeline) skipReason(ctx context.Context, n *Node, report *PipelineReport, index map[string]int) string {
+	if n.alwaysRun {
+		return ""
+	}
+
+	if ctx.Err() != nil {
+		return "pipeline canceled"
+	}
+
+	for _, dep := range n.deps {
+		if report.Nodes[index[dep]].Status != NodeSucceeded {
+			return fmt.Sprintf("dependency %q %s", dep, report.Nodes[index[dep]].Status)
+		}
+	}
+	return ""
+}
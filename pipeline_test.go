@@ -0,0 +1,133 @@
+package shellx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPipelineOrder 测试按依赖顺序执行
+func TestPipelineOrder(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "order.log")
+	step := func(name string) *Command {
+		return NewCmdStr("echo " + name + " >> " + log)
+	}
+
+	p := NewPipeline().SetLimit(2)
+	p.Add("test", step("test")).DependsOn("build")
+	p.Add("build", step("build")).DependsOn("fetch")
+	p.Add("fetch", step("fetch"))
+	p.Add("lint", step("lint")).DependsOn("fetch")
+
+	report, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("期望执行成功, 实际错误: %v", err)
+	}
+
+	data, _ := os.ReadFile(log)
+	lines := strings.Fields(string(data))
+	pos := make(map[string]int, len(lines))
+	for i, l := range lines {
+		pos[l] = i
+	}
+	if len(lines) != 4 || pos["fetch"] != 0 || pos["build"] > pos["test"] {
+		t.Errorf("执行顺序不符合依赖关系: %v", lines)
+	}
+
+	for _, n := range report.Nodes {
+		if n.Status != NodeSucceeded || n.Result.Start.IsZero() {
+			t.Errorf("节点 %s 应执行成功: %+v", n.Name, n)
+		}
+	}
+	if report.Nodes[0].Name != "test" {
+		t.Errorf("报告应按添加顺序排列, 实际第一个节点为 %s", report.Nodes[0].Name)
+	}
+}
+
+// TestPipelineSkipDownstream 测试上游失败时跳过下游并执行清理节点
+func TestPipelineSkipDownstream(t *testing.T) {
+	p := NewPipeline()
+	p.Add("build", NewCmdStr("exit 1"))
+	p.Add("test", NewCmd("echo", "test")).DependsOn("build")
+	p.Add("deploy", NewCmd("echo", "deploy")).DependsOn("test")
+	p.Add("other", NewCmd("echo", "other"))
+	p.Add("cleanup", NewCmd("echo", "cleanup")).DependsOn("deploy", "other").AlwaysRun()
+
+	report, err := p.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), `"build"`) {
+		t.Errorf("期望返回build节点的错误, 实际为: %v", err)
+	}
+
+	want := map[string]NodeStatus{
+		"build":   NodeFailed,
+		"test":    NodeSkipped,
+		"deploy":  NodeSkipped,
+		"other":   NodeSucceeded,
+		"cleanup": NodeSucceeded,
+	}
+	for name, status := range want {
+		n, ok := report.Node(name)
+		if !ok || n.Status != status {
+			t.Errorf("节点 %s 期望状态 %s, 实际为 %s", name, status, n.Status)
+		}
+	}
+}
+
+// TestPipelineValidate 测试依赖环和未知依赖检测
+func TestPipelineValidate(t *testing.T) {
+	t.Run("依赖环", func(t *testing.T) {
+		p := NewPipeline()
+		p.Add("a", NewCmd("echo")).DependsOn("c")
+		p.Add("b", NewCmd("echo")).DependsOn("a")
+		p.Add("c", NewCmd("echo")).DependsOn("b")
+
+		_, err := p.Run(context.Background())
+		var cycle *CycleError
+		if !errors.As(err, &cycle) {
+			t.Fatalf("期望 CycleError, 实际为: %v", err)
+		}
+		if len(cycle.Path) != 4 || cycle.Path[0] != cycle.Path[3] {
+			t.Errorf("环路径不符合预期: %v", cycle.Path)
+		}
+	})
+
+	t.Run("未知依赖", func(t *testing.T) {
+		p := NewPipeline()
+		p.Add("a", NewCmd("echo")).DependsOn("missing")
+
+		if err := p.Validate(); !errors.Is(err, ErrUnknownDependency) {
+			t.Errorf("期望 ErrUnknownDependency, 实际为: %v", err)
+		}
+	})
+}
+
+// TestPipelineCancel 测试取消流水线
+func TestPipelineCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	p := NewPipeline()
+	p.Add("long", NewCmd("sleep", "5").WithShell(ShellNone))
+	p.Add("next", NewCmd("echo", "next")).DependsOn("long")
+	p.Add("cleanup", NewCmd("echo", "cleanup")).DependsOn("next").AlwaysRun()
+
+	begin := time.Now()
+	report, err := p.Run(ctx)
+	if elapsed := time.Since(begin); elapsed > 3*time.Second {
+		t.Fatalf("取消后应尽快返回, 实际耗时 %v", elapsed)
+	}
+	if err == nil {
+		t.Error("期望返回错误")
+	}
+
+	if n, _ := report.Node("next"); n.Status != NodeSkipped {
+		t.Errorf("取消后未执行的节点应被跳过, 实际为 %s", n.Status)
+	}
+	if n, _ := report.Node("cleanup"); n.Status != NodeSucceeded {
+		t.Errorf("清理节点在取消后仍应执行成功, 实际为 %s: %v", n.Status, n.Result.Err)
+	}
+}
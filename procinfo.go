@@ -0,0 +1,66 @@
+// Package shellx 进程信息模块
+// 本文件定义了运行中进程的信息快照 ProcessInfo，包括：
+//   - 进程状态 (R/S/D/Z 等)、父进程ID和线程数
+//   - 内存占用 (RSS 和虚拟内存大小) 和累计 CPU 时间
+//   - 打开的文件描述符数量、命令行、工作目录和子进程列表
+//
+// 进程信息从 /proc 读取，仅支持 Linux，其他平台返回 errors.ErrUnsupported。
+package shellx
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// ProcessInfo 进程信息快照
+type ProcessInfo struct {
+	PID        int           // 进程ID
+	PPID       int           // 父进程ID
+	State      string        // 进程状态 (R运行、S睡眠、D不可中断睡眠、Z僵尸、T停止等)
+	Threads    int           // 线程数
+	RSS        int64         // 常驻内存大小 (字节)
+	VMSize     int64         // 虚拟内存大小 (字节)
+	UserTime   time.Duration // 用户态CPU时间
+	SystemTime time.Duration // 内核态CPU时间
+	FDs        int           // 打开的文件描述符数量, 无权限读取时为-1
+	Cmdline    []string      // 命令行参数
+	Cwd        string        // 工作目录, 无权限读取时为空
+	Children   []int         // 直接子进程ID
+}
+
+// CPUTime 返回累计CPU时间 (用户态 + 内核态)
+func (p *ProcessInfo) CPUTime() time.Duration {
+	return p.UserTime + p.SystemTime
+}
+
+// ReadProcessInfo 读取指定进程的信息
+//
+// 参数:
+//   - pid: 进程ID
+//
+// 返回:
+//   - *ProcessInfo: 进程信息快照
+//   - error: 进程不存在时返回包装了os.ErrProcessDone的错误, 非Linux平台返回errors.ErrUnsupported
+func ReadProcessInfo(pid int) (*ProcessInfo, error) {
+	return readProcessInfo(pid)
+}
+
+// ProcessInfo 读取命令进程的信息
+//
+// 返回:
+//   - *ProcessInfo: 进程信息快照
+//   - error: 命令未启动时返回ErrNoProcess, 命令已结束时返回包装了os.ErrProcessDone的错误
+//
+// 注意:
+//   - 仅支持Linux, 其他平台返回errors.ErrUnsupported
+//   - 通过shell执行的命令, 返回的是shell进程的信息, 实际命令通常在Children中
+func (c *Command) ProcessInfo() (*ProcessInfo, error) {
+	if c.process == nil {
+		return nil, ErrNoProcess
+	}
+	if !c.IsRunning() {
+		return nil, fmt.Errorf("process %d: %w", c.GetPID(), os.ErrProcessDone)
+	}
+	return readProcessInfo(c.GetPID())
+}
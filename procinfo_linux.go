@@ -0,0 +1,141 @@
+//go:build linux
+
+package shellx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks /proc/<pid>/stat 中CPU时间的单位 (USER_HZ), Linux上固定为100
+const clockTicks = 100
+
+// procStat /proc/<pid>/stat 中需要的字段
+type procStat struct {
+	state   string
+	ppid    int
+	utime   uint64
+	stime   uint64
+	threads int
+	start   uint64 // 启动时间 (系统启动后的时钟滴答数)
+	vsize   int64
+	rss     int64 // 页数
+}
+
+// readProcStat 读取并解析 /proc/<pid>/stat
+//
+// 参数:
+//   - pid: 进程ID
+//
+// 返回:
+//   - *procStat: 解析结果
+//   - error: 进程不存在时返回包装了os.ErrProcessDone的错误
+func readProcStat(pid int) (*procStat, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("process %d: %w", pid, os.ErrProcessDone)
+		}
+		return nil, err
+	}
+
+	// 进程名可能包含空格和括号, 从最后一个')'之后开始解析
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return nil, fmt.Errorf("process %d: malformed stat", pid)
+	}
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 22 {
+		return nil, fmt.Errorf("process %d: malformed stat", pid)
+	}
+
+	// fields[0] 对应 stat 的第3个字段 (state)
+	st := &procStat{state: fields[0]}
+	st.ppid, _ = strconv.Atoi(fields[1])
+	st.utime, _ = strconv.ParseUint(fields[11], 10, 64)
+	st.stime, _ = strconv.ParseUint(fields[12], 10, 64)
+	st.threads, _ = strconv.Atoi(fields[17])
+	st.start, _ = strconv.ParseUint(fields[19], 10, 64)
+	st.vsize, _ = strconv.ParseInt(fields[20], 10, 64)
+	st.rss, _ = strconv.ParseInt(fields[21], 10, 64)
+	return st, nil
+}
+
+// readProcessInfo 从 /proc 读取进程信息
+func readProcessInfo(pid int) (*ProcessInfo, error) {
+	st, err := readProcStat(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &ProcessInfo{
+		PID:        pid,
+		PPID:       st.ppid,
+		State:      st.state,
+		Threads:    st.threads,
+		RSS:        st.rss * int64(os.Getpagesize()),
+		VMSize:     st.vsize,
+		UserTime:   time.Duration(st.utime) * time.Second / clockTicks,
+		SystemTime: time.Duration(st.stime) * time.Second / clockTicks,
+		FDs:        -1,
+	}
+
+	dir := fmt.Sprintf("/proc/%d", pid)
+	if data, err := os.ReadFile(filepath.Join(dir, "cmdline")); err == nil {
+		data = bytes.TrimSuffix(data, []byte{0})
+		if len(data) > 0 {
+			info.Cmdline = strings.Split(string(data), "\x00")
+		}
+	}
+	if cwd, err := os.Readlink(filepath.Join(dir, "cwd")); err == nil {
+		info.Cwd = cwd
+	}
+	if entries, err := os.ReadDir(filepath.Join(dir, "fd")); err == nil {
+		info.FDs = len(entries)
+	}
+
+	info.Children, err = childPIDs(pid)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// childPIDs 扫描 /proc 获取直接子进程ID
+//
+// 参数:
+//   - pid: 父进程ID
+//
+// 返回:
+//   - []int: 子进程ID, 按ID升序排列
+//   - error: 读取 /proc 失败时返回错误
+func childPIDs(pid int) ([]int, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	var children []int
+	for _, e := range entries {
+		child, err := strconv.Atoi(e.Name())
+		if err != nil || child == pid {
+			continue
+		}
+
+		// 扫描期间进程可能已经退出, 忽略读取错误
+		st, err := readProcStat(child)
+		if err == nil && st.ppid == pid {
+			children = append(children, child)
+		}
+	}
+	slices.Sort(children)
+	return children, nil
+}
@@ -0,0 +1,89 @@
+//go:build linux
+
+package shellx
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestProcessInfo 测试读取运行中进程的信息
+func TestProcessInfo(t *testing.T) {
+	dir := t.TempDir()
+	cmd := NewCmd("sleep", "5").WithShell(ShellNone).WithWorkDir(dir)
+	if _, err := cmd.ProcessInfo(); !errors.Is(err, ErrNoProcess) {
+		t.Errorf("未启动时期望 ErrNoProcess, 实际为: %v", err)
+	}
+
+	if err := cmd.ExecAsync(); err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+	// 刚启动时进程可能还未完成exec, 等待内存信息可用
+	var info *ProcessInfo
+	var err error
+	for range 100 {
+		if info, err = cmd.ProcessInfo(); err != nil || info.RSS > 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	_ = cmd.Kill()
+	_ = cmd.Wait()
+	if err != nil {
+		t.Fatalf("读取进程信息失败: %v", err)
+	}
+
+	if info.PID != cmd.GetPID() || info.PPID != os.Getpid() {
+		t.Errorf("进程ID不符合预期: %+v", info)
+	}
+	if info.State != "S" && info.State != "R" {
+		t.Errorf("期望进程状态为 S 或 R, 实际为 %s", info.State)
+	}
+	if len(info.Cmdline) != 2 || info.Cmdline[0] != "sleep" || info.Cmdline[1] != "5" {
+		t.Errorf("命令行不符合预期: %q", info.Cmdline)
+	}
+	if info.Cwd != dir {
+		t.Errorf("工作目录期望为 %s, 实际为 %s", dir, info.Cwd)
+	}
+	if info.Threads < 1 || info.RSS <= 0 || info.VMSize <= 0 || info.FDs < 3 {
+		t.Errorf("资源信息不符合预期: %+v", info)
+	}
+
+	if _, err := cmd.ProcessInfo(); !errors.Is(err, os.ErrProcessDone) {
+		t.Errorf("结束后期望 os.ErrProcessDone, 实际为: %v", err)
+	}
+}
+
+// TestProcessInfoChildren 测试获取子进程列表
+func TestProcessInfoChildren(t *testing.T) {
+	cmd := NewCmdStr("sleep 5 & sleep 5 & wait").WithKillStrategy(KillStrategy{Group: true})
+	if err := cmd.ExecAsync(); err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+	defer func() {
+		_ = cmd.Kill()
+		_ = cmd.Wait()
+	}()
+
+	var info *ProcessInfo
+	for range 100 {
+		var err error
+		if info, err = cmd.ProcessInfo(); err != nil {
+			t.Fatalf("读取进程信息失败: %v", err)
+		}
+		if len(info.Children) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(info.Children) != 2 {
+		t.Fatalf("期望2个子进程, 实际为 %v", info.Children)
+	}
+
+	child, err := ReadProcessInfo(info.Children[0])
+	if err != nil || child.PPID != info.PID || child.Cmdline[0] != "sleep" {
+		t.Errorf("子进程信息不符合预期: %+v, 错误: %v", child, err)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package shellx
+
+import (
+	"errors"
+)
+
+// readProcessInfo 非Linux平台不支持读取进程信息
+func readProcessInfo(pid int) (*ProcessInfo, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,225 @@
+// Package shellx 进程树模块
+// 本文件提供了基于 /proc 的进程树遍历和终止功能，包括：
+//   - ProcessTree: 根据 /proc/*/stat 中的父进程ID构建后代进程树
+//   - KillTree/KillTreeWith: 向整个进程树发送信号并确认所有进程已退出
+//   - KillStrategy.Tree: 让 Command 的 Kill、Signal 和取消终止作用于整个进程树
+//
+// 进程树遍历仅支持 Linux。调用 setsid 脱离进程组的子进程仍然可以通过父进程ID找到，
+// 但已经退出的中间进程的子进程会被重新挂到 init 下，无法再被遍历到。
+package shellx
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultTreeKillTimeout 确认进程树退出的默认等待时间
+const defaultTreeKillTimeout = 5 * time.Second
+
+// ProcessNode 进程树节点
+type ProcessNode struct {
+	PID      int            // 进程ID
+	Cmdline  []string       // 命令行参数
+	Children []*ProcessNode // 直接子进程
+}
+
+// PIDs 返回进程树中的所有进程ID
+//
+// 返回:
+//   - []int: 后序排列的进程ID (叶子进程在前, 根进程在最后)
+func (n *ProcessNode) PIDs() []int {
+	var pids []int
+	var walk func(*ProcessNode)
+	walk = func(n *ProcessNode) {
+		for _, c := range n.Children {
+			walk(c)
+		}
+		pids = append(pids, n.PID)
+	}
+	walk(n)
+	return pids
+}
+
+// ProcessTree 构建以指定进程为根的后代进程树
+//
+// 参数:
+//   - pid: 根进程ID
+//
+// 返回:
+//   - *ProcessNode: 进程树根节点
+//   - error: 根进程不存在时返回包装了os.ErrProcessDone的错误, 非Linux平台返回errors.ErrUnsupported
+func ProcessTree(pid int) (*ProcessNode, error) {
+	return processTree(pid)
+}
+
+// TreeKillOptions 终止进程树的选项
+type TreeKillOptions struct {
+	// AllAtOnce 为true时先暂停(SIGSTOP)所有进程阻止其继续派生子进程, 再同时发送信号后恢复(SIGCONT);
+	// 为false时按叶子进程优先的顺序逐个发送信号
+	AllAtOnce bool
+	// Timeout 确认所有进程退出的等待时间, 小于等于0时使用5s
+	Timeout time.Duration
+}
+
+// TreeKillError 表示终止进程树后仍有进程存活
+type TreeKillError struct {
+	PID       int   // 根进程ID
+	Survivors []int // 超时后仍存活的进程ID
+}
+
+func (e *TreeKillError) Error() string {
+	return fmt.Sprintf("process tree %d: %d processes still alive after signal: %v", e.PID, len(e.Survivors), e.Survivors)
+}
+
+// KillTree 杀死整个进程树
+//
+// 参数:
+//   - pid: 根进程ID
+//   - sig: 信号, 为nil时使用SIGKILL
+//
+// 返回:
+//   - error: 等待超时后仍有进程存活时返回*TreeKillError, 非Linux平台返回errors.ErrUnsupported
+//
+// 注意:
+//   - 按叶子进程优先的顺序发送信号, 等同于KillTreeWith(pid, sig, TreeKillOptions{})
+func KillTree(pid int, sig os.Signal) error {
+	return KillTreeWith(pid, sig, TreeKillOptions{})
+}
+
+// KillTreeWith 使用指定选项向整个进程树发送信号并确认所有进程已退出
+//
+// 参数:
+//   - pid: 根进程ID
+//   - sig: 信号, 为nil时使用SIGKILL
+//   - opts: 终止选项
+//
+// 返回:
+//   - error: 等待超时后仍有进程存活时返回*TreeKillError, 非Linux平台返回errors.ErrUnsupported
+//
+// 注意:
+//   - 发送信号期间会多次扫描 /proc, 直到没有新派生的子进程
+//   - 僵尸进程视为已退出
+func KillTreeWith(pid int, sig os.Signal, opts TreeKillOptions) error {
+	if sig == nil {
+		sig = syscall.SIGKILL
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTreeKillTimeout
+	}
+
+	seen, err := signalTree(pid, sig, opts.AllAtOnce, nil)
+	if err != nil {
+		return err
+	}
+	return waitTree(pid, seen, opts.Timeout)
+}
+
+// useTree 判断终止策略是否作用于进程树
+func (ks KillStrategy) useTree() bool {
+	return ks.Tree && treeSupported
+}
+
+// useGroup 判断终止策略是否作用于进程组, 不支持进程树的平台上Tree回退为Group
+func (ks KillStrategy) useGroup() bool {
+	return ks.Group || (ks.Tree && !treeSupported)
+}
+
+// freezeOnSignal 判断向进程树发送信号时是否先暂停所有进程
+//
+// 注意:
+//   - 只有终止信号需要阻止进程在发送期间继续派生子进程
+//   - 转发的其他信号 (如SIGWINCH、SIGUSR1) 直接发送, 避免SIGCONT恢复被暂停的进程
+func freezeOnSignal(sig os.Signal) bool {
+	switch sig {
+	case syscall.SIGKILL, syscall.SIGTERM, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGHUP:
+		return true
+	default:
+		return false
+	}
+}
+
+// treeTerminator 按 KillStrategy.Tree 终止命令的进程树
+type treeTerminator struct {
+	mu      sync.Mutex
+	pid     int         // 根进程ID
+	seen    procSet     // 已经发送过信号的进程 (包含根进程的启动时间)
+	timer   *time.Timer // 等待时间后强制杀死进程树的定时器
+	stopped bool        // 命令已经结束, 不再发送信号
+}
+
+// begin 记录根进程的启动时间, 之后的信号只发给启动时间一致的根进程, 调用方需持有t.mu
+//
+// 参数:
+//   - pid: 根进程ID
+//
+// 返回:
+//   - error: 命令已经结束或根进程已经退出时返回包装了os.ErrProcessDone的错误
+func (t *treeTerminator) begin(pid int) error {
+	if t.stopped {
+		return os.ErrProcessDone
+	}
+	if t.seen != nil {
+		return nil
+	}
+
+	start, err := procStartTime(pid)
+	if err != nil {
+		return err
+	}
+	t.pid = pid
+	t.seen = procSet{pid: start}
+	return nil
+}
+
+// signal 向进程树发送信号, 记录发送过信号的进程供后续强制杀死
+func (t *treeTerminator) signal(pid int, sig os.Signal) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.begin(pid); err != nil {
+		return err
+	}
+	seen, err := signalTree(t.pid, sig, true, t.seen)
+	t.seen = seen
+	return err
+}
+
+// kill 杀死进程树, 包括之前发送过信号但已经脱离根进程的进程
+func (t *treeTerminator) kill(pid int) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if err := t.begin(pid); err != nil {
+		return err
+	}
+	seen, err := signalTree(t.pid, syscall.SIGKILL, true, t.seen)
+	t.seen = seen
+	return err
+}
+
+// killAfter 在等待时间后杀死进程树, 命令在此之前结束时不再杀死
+func (t *treeTerminator) killAfter(pid int, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.stopped {
+		return
+	}
+	t.timer = time.AfterFunc(d, func() {
+		_ = t.kill(pid)
+	})
+}
+
+// stop 在命令结束后调用, 停止等待中的强制杀死并忽略之后的信号
+func (t *treeTerminator) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.stopped = true
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+}
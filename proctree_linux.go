@@ -0,0 +1,242 @@
+//go:build linux
+
+package shellx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// treeSupported 当前平台是否支持进程树遍历
+const treeSupported = true
+
+// maxTreeScans 发送信号时扫描新派生子进程的最大轮数
+const maxTreeScans = 10
+
+// procSet 进程集合, 以进程ID和启动时间标识进程, 避免误伤复用了ID的新进程
+type procSet map[int]uint64
+
+// procEntry /proc 扫描得到的进程信息
+type procEntry struct {
+	ppid  int    // 父进程ID
+	start uint64 // 启动时间
+	state string // 进程状态
+}
+
+// scanProcs 扫描 /proc 获取所有进程
+//
+// 返回:
+//   - map[int]procEntry: 进程ID到进程信息的映射
+//   - error: 读取 /proc 失败时返回错误
+func scanProcs() (map[int]procEntry, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	procs := make(map[int]procEntry, len(entries))
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil {
+			continue
+		}
+
+		// 扫描期间进程可能已经退出, 忽略读取错误
+		if st, err := readProcStat(pid); err == nil {
+			procs[pid] = procEntry{ppid: st.ppid, start: st.start, state: st.state}
+		}
+	}
+	return procs, nil
+}
+
+// childrenIndex 构建父进程ID到子进程ID的索引
+func childrenIndex(procs map[int]procEntry) map[int][]int {
+	index := make(map[int][]int)
+	for pid, p := range procs {
+		index[p.ppid] = append(index[p.ppid], pid)
+	}
+	for _, children := range index {
+		slices.Sort(children)
+	}
+	return index
+}
+
+// processTree 构建进程树
+func processTree(pid int) (*ProcessNode, error) {
+	procs, err := scanProcs()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := procs[pid]; !ok {
+		return nil, fmt.Errorf("process %d: %w", pid, os.ErrProcessDone)
+	}
+
+	index := childrenIndex(procs)
+	var build func(pid int) *ProcessNode
+	build = func(pid int) *ProcessNode {
+		n := &ProcessNode{PID: pid, Cmdline: readCmdline(pid)}
+		for _, c := range index[pid] {
+			n.Children = append(n.Children, build(c))
+		}
+		return n
+	}
+	return build(pid), nil
+}
+
+// readCmdline 读取进程的命令行参数
+func readCmdline(pid int) []string {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "cmdline"))
+	if err != nil {
+		return nil
+	}
+	s := strings.TrimSuffix(string(data), "\x00")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\x00")
+}
+
+// procStartTime 读取进程的启动时间
+//
+// 参数:
+//   - pid: 进程ID
+//
+// 返回:
+//   - uint64: 启动时间 (系统启动后的时钟滴答数)
+//   - error: 进程不存在时返回包装了os.ErrProcessDone的错误
+func procStartTime(pid int) (uint64, error) {
+	st, err := readProcStat(pid)
+	if err != nil {
+		return 0, err
+	}
+	return st.start, nil
+}
+
+// signalTree 向进程树发送信号
+//
+// 参数:
+//   - pid: 根进程ID
+//   - sig: 信号
+//   - allAtOnce: 是否先暂停所有进程再同时发送信号, 否则按叶子进程优先的顺序发送
+//   - seen: 之前已经发送过信号的进程, 其中仍然存活的进程及其后代也会收到信号, 可以为nil
+//
+// 注意:
+//   - seen中记录了根进程时, 只有启动时间一致才会向根进程及其后代发送信号, 避免PID被复用后误杀
+//
+// 返回:
+//   - procSet: 所有发送过信号的进程 (包含seen)
+//   - error: 信号类型不支持或读取 /proc 失败时返回错误
+func signalTree(pid int, sig os.Signal, allAtOnce bool, seen procSet) (procSet, error) {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return seen, errors.New("unsupported signal type")
+	}
+
+	all := make(procSet, len(seen))
+	for p, start := range seen {
+		all[p] = start
+	}
+
+	signaled := make(procSet)
+	for range maxTreeScans {
+		procs, err := scanProcs()
+		if err != nil {
+			return all, err
+		}
+
+		// 根进程和之前记录的进程都作为遍历起点
+		var roots []int
+		if _, ok := all[pid]; !ok {
+			roots = append(roots, pid)
+		}
+		for p, start := range all {
+			if e, ok := procs[p]; ok && e.start == start {
+				roots = append(roots, p)
+			}
+		}
+
+		// 后序遍历得到叶子优先的顺序, 跳过已经发送过信号的进程
+		index := childrenIndex(procs)
+		var order []int
+		visited := make(map[int]bool)
+		var walk func(p int)
+		walk = func(p int) {
+			if visited[p] {
+				return
+			}
+			visited[p] = true
+			for _, c := range index[p] {
+				walk(c)
+			}
+			e, ok := procs[p]
+			if !ok {
+				return
+			}
+			if start, done := signaled[p]; done && start == e.start {
+				return
+			}
+			order = append(order, p)
+		}
+		for _, r := range roots {
+			walk(r)
+		}
+
+		if len(order) == 0 {
+			break
+		}
+
+		if allAtOnce {
+			for _, p := range order {
+				_ = syscall.Kill(p, syscall.SIGSTOP)
+			}
+		}
+		for _, p := range order {
+			_ = syscall.Kill(p, s)
+			signaled[p] = procs[p].start
+			all[p] = procs[p].start
+		}
+		if allAtOnce && s != syscall.SIGKILL && s != syscall.SIGSTOP {
+			for _, p := range order {
+				_ = syscall.Kill(p, syscall.SIGCONT)
+			}
+		}
+	}
+	return all, nil
+}
+
+// waitTree 等待进程集合中的所有进程退出
+//
+// 参数:
+//   - pid: 根进程ID, 用于错误信息
+//   - procs: 需要等待的进程
+//   - timeout: 等待时间
+//
+// 返回:
+//   - error: 超时后仍有进程存活时返回*TreeKillError
+func waitTree(pid int, procs procSet, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		var alive []int
+		for p, start := range procs {
+			st, err := readProcStat(p)
+			if err == nil && st.start == start && st.state != "Z" && st.state != "X" {
+				alive = append(alive, p)
+			}
+		}
+		if len(alive) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			slices.Sort(alive)
+			return &TreeKillError{PID: pid, Survivors: alive}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
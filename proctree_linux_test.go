@@ -0,0 +1,213 @@
+//go:build linux
+
+package shellx
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// waitTreeSize 等待进程树达到指定的进程数量
+func waitTreeSize(t *testing.T, pid, size int) *ProcessNode {
+	t.Helper()
+
+	var tree *ProcessNode
+	for range 200 {
+		var err error
+		if tree, err = ProcessTree(pid); err != nil {
+			t.Fatalf("构建进程树失败: %v", err)
+		}
+		if len(tree.PIDs()) == size {
+			return tree
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("期望进程树包含 %d 个进程, 实际为 %v", size, tree.PIDs())
+	return nil
+}
+
+// alive 判断进程是否仍然存活 (僵尸进程视为已退出)
+func alive(pid int) bool {
+	st, err := readProcStat(pid)
+	return err == nil && st.state != "Z"
+}
+
+// TestProcessTree 测试构建后代进程树
+func TestProcessTree(t *testing.T) {
+	cmd := NewCmdStr(`sh -c "sleep 5" & sleep 5 & wait`).WithKillStrategy(KillStrategy{Tree: true})
+	if err := cmd.ExecAsync(); err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+	defer func() {
+		_ = cmd.Kill()
+		_ = cmd.Wait()
+	}()
+
+	tree := waitTreeSize(t, cmd.GetPID(), 4)
+	if tree.PID != cmd.GetPID() || len(tree.Children) != 2 {
+		t.Fatalf("进程树结构不符合预期: %+v", tree)
+	}
+
+	pids := tree.PIDs()
+	if pids[len(pids)-1] != tree.PID {
+		t.Errorf("根进程应排在最后, 实际为 %v", pids)
+	}
+}
+
+// TestKillStrategyTree 测试终止脱离进程组的后代进程
+func TestKillStrategyTree(t *testing.T) {
+	if _, err := exec.LookPath("setsid"); err != nil {
+		t.Skip("需要setsid命令")
+	}
+
+	cmd := NewCmdStr("setsid sleep 30 & sleep 30 & wait").WithKillStrategy(KillStrategy{Tree: true})
+	if err := cmd.ExecAsync(); err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+	pids := waitTreeSize(t, cmd.GetPID(), 3).PIDs()
+
+	if err := cmd.Kill(); err != nil {
+		t.Fatalf("终止进程树失败: %v", err)
+	}
+	_ = cmd.Wait()
+
+	for _, pid := range pids {
+		for i := 0; i < 100 && alive(pid); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+		if alive(pid) {
+			t.Errorf("进程 %d 应已被终止", pid)
+		}
+	}
+}
+
+// TestKillStrategyTreeSignal 测试进程树模式下非终止信号直接发送, 不会暂停和恢复进程
+func TestKillStrategyTreeSignal(t *testing.T) {
+	cmd := NewCmd("sleep", "30").WithShell(ShellNone).WithKillStrategy(KillStrategy{Tree: true})
+	if err := cmd.ExecAsync(); err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+	defer func() {
+		_ = cmd.Kill()
+		_ = cmd.Wait()
+	}()
+
+	// 冻结后再发送信号会在最后发送SIGCONT, 使SIGSTOP失效
+	if err := cmd.Signal(syscall.SIGSTOP); err != nil {
+		t.Fatalf("发送信号失败: %v", err)
+	}
+	if err := cmd.Signal(syscall.SIGWINCH); err != nil {
+		t.Fatalf("发送信号失败: %v", err)
+	}
+
+	var state string
+	for range 100 {
+		st, err := readProcStat(cmd.GetPID())
+		if err != nil {
+			t.Fatalf("读取进程状态失败: %v", err)
+		}
+		if state = st.state; state == "T" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if state != "T" {
+		t.Errorf("期望进程保持暂停状态, 实际为 %q", state)
+	}
+}
+
+// TestKillTreeSurvivors 测试进程忽略信号时报告存活的进程
+func TestKillTreeSurvivors(t *testing.T) {
+	cmd := NewCmdStr(`trap "" TERM; sleep 30 & wait; wait`).WithKillStrategy(KillStrategy{Tree: true})
+	if err := cmd.ExecAsync(); err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+	defer func() {
+		_ = cmd.Kill()
+		_ = cmd.Wait()
+	}()
+	waitTreeSize(t, cmd.GetPID(), 2)
+
+	err := KillTreeWith(cmd.GetPID(), nil, TreeKillOptions{AllAtOnce: true, Timeout: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("SIGKILL应终止所有进程, 实际错误: %v", err)
+	}
+
+	cmd2 := NewCmdStr(`trap "" TERM; while :; do sleep 0.05; done`).WithKillStrategy(KillStrategy{Tree: true})
+	if err := cmd2.ExecAsync(); err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+	defer func() {
+		_ = cmd2.Kill()
+		_ = cmd2.Wait()
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	err = KillTreeWith(cmd2.GetPID(), syscall.SIGTERM, TreeKillOptions{Timeout: 300 * time.Millisecond})
+	var treeErr *TreeKillError
+	if !errors.As(err, &treeErr) || !slices.Contains(treeErr.Survivors, cmd2.GetPID()) {
+		t.Errorf("忽略SIGTERM的进程应被报告为存活, 实际为: %v", err)
+	}
+}
+
+// TestTreeTerminatorReusedPID 测试根进程的PID被复用后不再向其发送信号
+func TestTreeTerminatorReusedPID(t *testing.T) {
+	other := exec.Command("sleep", "30")
+	if err := other.Start(); err != nil {
+		t.Fatalf("启动进程失败: %v", err)
+	}
+	defer func() {
+		_ = other.Process.Kill()
+		_ = other.Wait()
+	}()
+
+	pid := other.Process.Pid
+	start, err := procStartTime(pid)
+	if err != nil {
+		t.Fatalf("读取启动时间失败: %v", err)
+	}
+
+	// 模拟记录的根进程已退出, 相同的PID分配给了无关进程
+	term := &treeTerminator{pid: pid, seen: procSet{pid: start + 1}}
+	if err := term.kill(pid); err != nil {
+		t.Fatalf("杀死进程树失败: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if !alive(pid) {
+		t.Error("启动时间不一致的进程不应收到信号")
+	}
+}
+
+// TestKillStrategyTreeStopTimer 测试命令结束后停止等待中的强制杀死
+func TestKillStrategyTreeStopTimer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cmd := NewCmdStr(`trap 'exit 0' TERM; sleep 30 & wait`).
+		WithContext(ctx).
+		WithKillStrategy(KillStrategy{Signal: syscall.SIGTERM, GracePeriod: time.Hour, Tree: true})
+	if err := cmd.ExecAsync(); err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+	waitTreeSize(t, cmd.GetPID(), 2)
+
+	cancel()
+	_ = cmd.Wait()
+
+	term := cmd.terminator
+	term.mu.Lock()
+	defer term.mu.Unlock()
+	if !term.stopped {
+		t.Error("命令结束后终止器应停止")
+	}
+	if term.timer == nil || term.timer.Stop() {
+		t.Error("命令结束后应停止等待中的强制杀死")
+	}
+	if err := term.begin(cmd.GetPID()); !errors.Is(err, os.ErrProcessDone) {
+		t.Errorf("命令结束后不应再发送信号, 实际为: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !linux
+
+package shellx
+
+import (
+	"errors"
+	"os"
+	"time"
+)
+
+// treeSupported 当前平台是否支持进程树遍历
+const treeSupported = false
+
+// procSet 进程集合, 非Linux平台不使用
+type procSet map[int]uint64
+
+// processTree 非Linux平台不支持进程树遍历
+func processTree(pid int) (*ProcessNode, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// procStartTime 非Linux平台不支持读取进程启动时间
+func procStartTime(pid int) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// signalTree 非Linux平台不支持进程树遍历
+func signalTree(pid int, sig os.Signal, allAtOnce bool, seen procSet) (procSet, error) {
+	return seen, errors.ErrUnsupported
+}
+
+// waitTree 非Linux平台不支持进程树遍历
+func waitTree(pid int, procs procSet, timeout time.Duration) error {
+	return errors.ErrUnsupported
+}
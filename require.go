@@ -0,0 +1,569 @@
+// Package shellx 工具版本要求模块
+// 本文件提供了检查外部工具最低版本的功能，包括：
+//   - Require/RequireAll: 查找工具、执行版本探测命令并检查版本约束
+//   - SemVer/ParseSemVer: 语义化版本的解析与比较
+//   - 版本约束：支持比较运算符 (>=、>、<=、<、=、!=)、空格分隔的范围、^、~ 以及 || 组合
+//   - 探测缓存：按可执行文件路径和修改时间缓存探测结果
+package shellx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultProbeTimeout 版本探测命令的默认超时时间
+const defaultProbeTimeout = 10 * time.Second
+
+var (
+	// ErrVersionNotFound 表示无法从探测输出中提取版本号
+	ErrVersionNotFound = errors.New("version not found in probe output")
+	// ErrVersionMismatch 表示工具版本不满足约束
+	ErrVersionMismatch = errors.New("version does not satisfy constraint")
+)
+
+// defaultVersionPatterns 默认的版本号提取正则, 按顺序尝试
+//
+// 注意:
+//   - 不提取预发布标识, 发行版的打包后缀 (如 1.2.3-1ubuntu1) 会被误判为预发布版本
+var defaultVersionPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\d+\.\d+(?:\.\d+)?`),
+	regexp.MustCompile(`\d+`),
+}
+
+// SemVer 语义化版本
+type SemVer struct {
+	Major int    // 主版本号
+	Minor int    // 次版本号
+	Patch int    // 修订号
+	Pre   string // 预发布标识, 如 "rc.1"
+}
+
+// String 返回版本的字符串表示
+func (v SemVer) String() string {
+	s := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	return s
+}
+
+// Compare 比较两个版本
+//
+// 参数:
+//   - o: 另一个版本
+//
+// 返回:
+//   - int: v小于o时为-1, 相等时为0, 大于时为1
+//
+// 注意:
+//   - 带预发布标识的版本小于对应的正式版本, 预发布标识之间按字符串比较
+func (v SemVer) Compare(o SemVer) int {
+	for _, d := range [...]int{v.Major - o.Major, v.Minor - o.Minor, v.Patch - o.Patch} {
+		switch {
+		case d < 0:
+			return -1
+		case d > 0:
+			return 1
+		}
+	}
+
+	switch {
+	case v.Pre == o.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case o.Pre == "":
+		return -1
+	}
+	return strings.Compare(v.Pre, o.Pre)
+}
+
+// ParseSemVer 解析版本字符串
+//
+// 参数:
+//   - s: 版本字符串, 如 "2.30"、"v4.4.20"、"1.2.3-rc.1", 缺少的部分视为0
+//
+// 返回:
+//   - SemVer: 解析后的版本
+//   - error: 格式错误时返回错误
+func ParseSemVer(s string) (SemVer, error) {
+	v, _, err := parseVersionParts(s)
+	return v, err
+}
+
+// parseVersionParts 解析版本字符串并返回显式给出的部分数量
+//
+// 参数:
+//   - s: 版本字符串, 部分可以是 x 或 * 通配符
+//
+// 返回:
+//   - SemVer: 解析后的版本
+//   - int: 显式给出的数字部分数量 (遇到通配符时停止计数)
+//   - error: 格式错误时返回错误
+func parseVersionParts(s string) (SemVer, int, error) {
+	raw := s
+	s = strings.TrimPrefix(strings.TrimSpace(s), "v")
+
+	var v SemVer
+	s, v.Pre, _ = strings.Cut(s, "-")
+	s, _, _ = strings.Cut(s, "+") // 忽略构建元数据
+
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 || s == "" {
+		return SemVer{}, 0, fmt.Errorf("invalid version %q", raw)
+	}
+
+	nums := [3]*int{&v.Major, &v.Minor, &v.Patch}
+	n := 0
+	for i, p := range parts {
+		if p == "x" || p == "X" || p == "*" {
+			break
+		}
+		num, err := strconv.Atoi(p)
+		if err != nil || num < 0 {
+			return SemVer{}, 0, fmt.Errorf("invalid version %q", raw)
+		}
+		*nums[i] = num
+		n++
+	}
+	return v, n, nil
+}
+
+// comparator 单个版本比较条件
+type comparator struct {
+	op string // 运算符
+	v  SemVer // 比较的版本
+}
+
+// match 判断版本是否满足比较条件
+func (c comparator) match(v SemVer) bool {
+	cmp := v.Compare(c.v)
+	switch c.op {
+	case ">=":
+		return cmp >= 0
+	case ">":
+		return cmp > 0
+	case "<=":
+		return cmp <= 0
+	case "<":
+		return cmp < 0
+	case "!=":
+		return cmp != 0
+	default:
+		return cmp == 0
+	}
+}
+
+// Constraint 版本约束
+type Constraint struct {
+	raw  string
+	sets [][]comparator // 多组条件之间为"或", 组内条件为"与"
+}
+
+// ParseConstraint 解析版本约束
+//
+// 参数:
+//   - s: 约束字符串, 为空或 * 时匹配任意版本
+//
+// 返回:
+//   - *Constraint: 版本约束
+//   - error: 格式错误时返回错误
+//
+// 注意:
+//   - 比较运算符: >=2.30、>1、<=3、<3.0.0、=2.30.1、!=2.31.0, 运算符与版本之间可以有空格
+//   - 范围: 空格分隔的多个条件需同时满足, 如 ">=1.2 <2"
+//   - ^1.2.3 表示 >=1.2.3 <2.0.0, 主版本为0时为 >=0.2.3 <0.3.0
+//   - ~1.2.3 表示 >=1.2.3 <1.3.0, ~1 表示 >=1.0.0 <2.0.0
+//   - 不带运算符或使用 = 的部分版本按前缀匹配, 如 "2.30" 和 "2.30.x" 匹配所有 2.30.* 版本
+//   - || 分隔的多组条件满足任意一组即可, 如 "^1.2 || ^2"
+func ParseConstraint(s string) (*Constraint, error) {
+	c := &Constraint{raw: s}
+	for alt := range strings.SplitSeq(s, "||") {
+		set, err := parseComparators(alt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid constraint %q: %w", s, err)
+		}
+		c.sets = append(c.sets, set)
+	}
+	return c, nil
+}
+
+// String 返回约束的原始字符串
+func (c *Constraint) String() string {
+	return c.raw
+}
+
+// Check 判断版本是否满足约束
+//
+// 参数:
+//   - v: 版本
+//
+// 返回:
+//   - bool: 是否满足
+func (c *Constraint) Check(v SemVer) bool {
+	for _, set := range c.sets {
+		ok := true
+		for _, cmp := range set {
+			if !cmp.match(v) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseComparators 解析一组空格分隔的比较条件
+func parseComparators(s string) ([]comparator, error) {
+	var set []comparator
+	fields := strings.Fields(s)
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if field == "*" {
+			continue
+		}
+
+		op := ""
+		for _, prefix := range [...]string{">=", "<=", "!=", "==", ">", "<", "=", "^", "~"} {
+			if strings.HasPrefix(field, prefix) {
+				op = prefix
+				break
+			}
+		}
+		ver := field[len(op):]
+
+		// 运算符与版本之间有空格
+		if ver == "" && op != "" {
+			if i+1 >= len(fields) {
+				return nil, fmt.Errorf("missing version after %q", op)
+			}
+			i++
+			ver = fields[i]
+		}
+
+		v, n, err := parseVersionParts(ver)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, expandComparator(op, v, n)...)
+	}
+	return set, nil
+}
+
+// expandComparator 将 ^、~ 和部分版本展开为基本比较条件
+//
+// 参数:
+//   - op: 运算符
+//   - v: 版本
+//   - n: 显式给出的数字部分数量
+//
+// 返回:
+//   - []comparator: 基本比较条件
+func expandComparator(op string, v SemVer, n int) []comparator {
+	switch op {
+	case "^":
+		upper := SemVer{Major: v.Major + 1}
+		switch {
+		case v.Major == 0 && n >= 2 && (v.Minor > 0 || n == 2):
+			upper = SemVer{Minor: v.Minor + 1}
+		case v.Major == 0 && n == 3:
+			upper = SemVer{Patch: v.Patch + 1}
+		}
+		return []comparator{{">=", v}, {"<", upper}}
+
+	case "~":
+		upper := SemVer{Major: v.Major + 1}
+		if n >= 2 {
+			upper = SemVer{Major: v.Major, Minor: v.Minor + 1}
+		}
+		return []comparator{{">=", v}, {"<", upper}}
+
+	case "", "=", "==":
+		// 部分版本按前缀匹配
+		switch n {
+		case 0:
+			return nil
+		case 1:
+			return []comparator{{">=", v}, {"<", SemVer{Major: v.Major + 1}}}
+		case 2:
+			return []comparator{{">=", v}, {"<", SemVer{Major: v.Major, Minor: v.Minor + 1}}}
+		}
+		return []comparator{{"=", v}}
+	}
+	return []comparator{{op, v}}
+}
+
+// Requirement 工具版本要求
+type Requirement struct {
+	Name       string           // 工具名称或路径, 通过 FindCmd 查找
+	Constraint string           // 版本约束, 为空时只检查工具是否存在, 参见 ParseConstraint
+	Args       []string         // 版本探测参数, 为空时使用 --version
+	Patterns   []*regexp.Regexp // 版本号提取正则, 按顺序尝试, 有捕获组时使用第一个捕获组, 为空时使用默认规则
+	Timeout    time.Duration    // 探测命令超时时间, 小于等于0时使用10s
+}
+
+// RequireResult 单个工具的检查结果
+type RequireResult struct {
+	Name       string // 工具名称
+	Constraint string // 版本约束
+	Path       string // 工具的绝对路径, 未找到时为空
+	Version    SemVer // 探测到的版本
+	Output     string // 探测命令的输出
+	Err        error  // 检查错误, 满足要求时为nil
+}
+
+// OK 判断是否满足要求
+func (r *RequireResult) OK() bool {
+	return r.Err == nil
+}
+
+// RequireError 表示工具不满足版本要求
+type RequireError struct {
+	Name       string // 工具名称
+	Constraint string // 版本约束
+	Path       string // 工具的绝对路径, 未找到时为空
+	Err        error  // 具体原因
+}
+
+func (e *RequireError) Error() string {
+	return fmt.Sprintf("requirement %s %s: %v", e.Name, e.Constraint, e.Err)
+}
+
+func (e *RequireError) Unwrap() error {
+	return e.Err
+}
+
+// RequireReport 多个工具的检查报告
+type RequireReport struct {
+	Results []*RequireResult // 按传入顺序排列的检查结果
+}
+
+// OK 判断是否所有工具都满足要求
+func (r *RequireReport) OK() bool {
+	return len(r.Failed()) == 0
+}
+
+// Failed 获取不满足要求的检查结果
+func (r *RequireReport) Failed() []*RequireResult {
+	var failed []*RequireResult
+	for _, res := range r.Results {
+		if !res.OK() {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// String 返回报告的文本形式, 每个工具一行
+func (r *RequireReport) String() string {
+	var b strings.Builder
+	for _, res := range r.Results {
+		status := "ok"
+		if !res.OK() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "%-4s %s %s", status, res.Name, res.Constraint)
+		if res.Path != "" {
+			fmt.Fprintf(&b, " (%s %s)", res.Path, res.Version)
+		}
+		if res.Err != nil {
+			fmt.Fprintf(&b, ": %v", errors.Unwrap(res.Err))
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// Require 检查工具是否存在且版本满足约束
+//
+// 参数:
+//   - name: 工具名称或路径
+//   - constraint: 版本约束, 如 ">=2.30"、"^4"、"~1.2", 为空时只检查工具是否存在
+//
+// 返回:
+//   - *RequireResult: 检查结果
+//   - error: 不满足要求时返回*RequireError, 可以通过errors.Is判断ErrVersionMismatch、ErrVersionNotFound或exec.ErrNotFound
+//
+// 示例:
+//
+//	if _, err := shellx.Require("git", ">=2.30"); err != nil {
+//		log.Fatal(err)
+//	}
+func Require(name, constraint string) (*RequireResult, error) {
+	res := Requirement{Name: name, Constraint: constraint}.Check()
+	return res, res.Err
+}
+
+// RequireAll 检查多个工具的版本要求
+//
+// 参数:
+//   - reqs: 工具版本要求列表
+//
+// 返回:
+//   - *RequireReport: 检查报告, 包含所有工具的结果
+//   - error: 使用errors.Join聚合的所有不满足要求的错误
+//
+// 示例:
+//
+//	report, err := shellx.RequireAll(
+//		shellx.Requirement{Name: "git", Constraint: ">=2.30"},
+//		shellx.Requirement{Name: "bash", Constraint: ">=4"},
+//	)
+//	if err != nil {
+//		fmt.Print(report)
+//	}
+func RequireAll(reqs ...Requirement) (*RequireReport, error) {
+	report := &RequireReport{Results: make([]*RequireResult, len(reqs))}
+
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		wg.Go(func() {
+			report.Results[i] = req.Check()
+		})
+	}
+	wg.Wait()
+
+	errs := make([]error, 0, len(reqs))
+	for _, res := range report.Results {
+		errs = append(errs, res.Err)
+	}
+	return report, errors.Join(errs...)
+}
+
+// Check 检查工具版本要求
+//
+// 返回:
+//   - *RequireResult: 检查结果, 不满足要求时Err为*RequireError
+func (r Requirement) Check() *RequireResult {
+	res := &RequireResult{Name: r.Name, Constraint: r.Constraint}
+	fail := func(err error) *RequireResult {
+		res.Err = &RequireError{Name: r.Name, Constraint: r.Constraint, Path: res.Path, Err: err}
+		return res
+	}
+
+	constraint, err := ParseConstraint(r.Constraint)
+	if err != nil {
+		return fail(err)
+	}
+
+	path, err := FindCmd(r.Name)
+	if err != nil {
+		return fail(err)
+	}
+	res.Path = path
+
+	probe, err := r.probe(path)
+	if err != nil {
+		// 没有版本约束时只检查工具是否存在
+		if strings.TrimSpace(r.Constraint) == "" {
+			return res
+		}
+		return fail(err)
+	}
+	res.Version, res.Output = probe.version, probe.output
+
+	if !constraint.Check(res.Version) {
+		return fail(fmt.Errorf("%w: found %s", ErrVersionMismatch, res.Version))
+	}
+	return res
+}
+
+// probeResult 版本探测结果
+type probeResult struct {
+	version SemVer // 版本
+	output  string // 探测命令的输出
+}
+
+// probeKey 版本探测缓存键
+type probeKey struct {
+	path     string    // 可执行文件路径
+	mtime    time.Time // 可执行文件修改时间
+	args     string    // 探测参数
+	patterns string    // 提取正则
+}
+
+// probeCache 版本探测结果缓存
+var probeCache sync.Map
+
+// ClearRequireCache 清空版本探测缓存
+func ClearRequireCache() {
+	probeCache.Clear()
+}
+
+// probe 执行版本探测命令并提取版本号, 结果按可执行文件路径和修改时间缓存
+func (r Requirement) probe(path string) (probeResult, error) {
+	args := r.Args
+	if len(args) == 0 {
+		args = []string{"--version"}
+	}
+	patterns := r.Patterns
+	if len(patterns) == 0 {
+		patterns = defaultVersionPatterns
+	}
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = defaultProbeTimeout
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return probeResult{}, err
+	}
+	key := probeKey{path: path, mtime: info.ModTime(), args: strings.Join(args, "\x00")}
+	for _, p := range patterns {
+		key.patterns += p.String() + "\x00"
+	}
+	if v, ok := probeCache.Load(key); ok {
+		return v.(probeResult), nil
+	}
+
+	out, execErr := NewCmd(path, args...).WithShell(ShellNone).WithTimeout(timeout).ExecOutput()
+	output := strings.TrimSpace(string(out))
+
+	// 部分工具打印版本后以非0退出码结束, 能提取到版本号时忽略执行错误
+	version, ok := extractVersion(output, patterns)
+	if !ok {
+		if execErr != nil {
+			return probeResult{}, execErr
+		}
+		return probeResult{}, fmt.Errorf("%w: %q", ErrVersionNotFound, firstLine(output))
+	}
+
+	res := probeResult{version: version, output: output}
+	probeCache.Store(key, res)
+	return res, nil
+}
+
+// extractVersion 按顺序使用正则从输出中提取版本号
+func extractVersion(output string, patterns []*regexp.Regexp) (SemVer, bool) {
+	for _, re := range patterns {
+		m := re.FindStringSubmatch(output)
+		if m == nil {
+			continue
+		}
+
+		s := m[0]
+		if len(m) > 1 {
+			s = m[1]
+		}
+		if v, err := ParseSemVer(s); err == nil {
+			return v, true
+		}
+	}
+	return SemVer{}, false
+}
+
+// firstLine 返回字符串的第一行
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
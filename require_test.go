@@ -0,0 +1,190 @@
+package shellx
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestParseConstraint 测试版本约束解析与匹配
+func TestParseConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{">=2.30", "2.39.2", true},
+		{">=2.30", "2.29.9", false},
+		{">= 2.30", "2.30.0", true},
+		{">1 <2", "1.5.0", true},
+		{">1 <2", "2.0.0", false},
+		{"^1.2.3", "1.9.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"~1.2.3", "1.2.9", true},
+		{"~1.2.3", "1.3.0", false},
+		{"~4", "4.9.1", true},
+		{"2.30", "2.30.5", true},
+		{"2.30.x", "2.31.0", false},
+		{"=1.2.3", "1.2.3", true},
+		{"!=1.2.3", "1.2.3", false},
+		{"^1 || ^3", "3.1.0", true},
+		{"^1 || ^3", "2.1.0", false},
+		{"", "0.0.1", true},
+		{"*", "9.9.9", true},
+		{">=1.2.3", "1.2.3-rc.1", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.constraint+" "+tt.version, func(t *testing.T) {
+			c, err := ParseConstraint(tt.constraint)
+			if err != nil {
+				t.Fatalf("解析约束失败: %v", err)
+			}
+			v, err := ParseSemVer(tt.version)
+			if err != nil {
+				t.Fatalf("解析版本失败: %v", err)
+			}
+			if got := c.Check(v); got != tt.want {
+				t.Errorf("期望 %v, 实际为 %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("格式错误", func(t *testing.T) {
+		for _, s := range []string{">=", ">=a.b", "1.2.3.4"} {
+			if _, err := ParseConstraint(s); err == nil {
+				t.Errorf("期望 %q 解析失败", s)
+			}
+		}
+	})
+}
+
+// writeFakeTool 创建打印指定版本信息的可执行文件
+func writeFakeTool(t *testing.T, dir, name, output string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	script := "#!/bin/sh\n[ \"$1\" = \"--version\" ] || [ \"$1\" = \"-V\" ] || exit 2\necho '" + output + "'\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("创建可执行文件失败: %v", err)
+	}
+	return path
+}
+
+// TestRequire 测试工具版本要求检查
+func TestRequire(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用shell脚本作为可执行文件")
+	}
+
+	dir := t.TempDir()
+	tool := writeFakeTool(t, dir, "fake-git", "fake-git version 2.31.4")
+
+	t.Run("满足约束", func(t *testing.T) {
+		res, err := Require(tool, ">=2.30")
+		if err != nil {
+			t.Fatalf("期望满足要求, 实际为 %v", err)
+		}
+		if res.Path != tool || res.Version.String() != "2.31.4" {
+			t.Fatalf("结果不正确: %+v", res)
+		}
+	})
+
+	t.Run("版本不满足", func(t *testing.T) {
+		res, err := Require(tool, "^3")
+		if !errors.Is(err, ErrVersionMismatch) {
+			t.Fatalf("期望 ErrVersionMismatch, 实际为 %v", err)
+		}
+		var reqErr *RequireError
+		if !errors.As(err, &reqErr) || reqErr.Path != tool {
+			t.Fatalf("期望 *RequireError, 实际为 %#v", err)
+		}
+		if res.OK() || res.Version.Major != 2 {
+			t.Fatalf("结果不正确: %+v", res)
+		}
+	})
+
+	t.Run("工具不存在", func(t *testing.T) {
+		if _, err := Require("shellx-no-such-tool", ">=1"); !errors.Is(err, exec.ErrNotFound) {
+			t.Fatalf("期望 exec.ErrNotFound, 实际为 %v", err)
+		}
+	})
+
+	t.Run("自定义探测参数和正则", func(t *testing.T) {
+		custom := writeFakeTool(t, dir, "fake-custom", "build 7 (release 1.4)")
+		res := Requirement{
+			Name:       custom,
+			Constraint: "~1.4",
+			Args:       []string{"-V"},
+			Patterns:   []*regexp.Regexp{regexp.MustCompile(`release (\d+\.\d+)`)},
+		}.Check()
+		if !res.OK() || res.Version.String() != "1.4.0" {
+			t.Fatalf("结果不正确: %+v", res)
+		}
+
+		// 探测失败时有约束则报告错误, 没有约束只检查是否存在
+		if res := (Requirement{Name: custom, Constraint: ">=1", Args: []string{"--bad"}}).Check(); res.OK() {
+			t.Fatal("期望探测失败")
+		}
+		if res := (Requirement{Name: custom, Args: []string{"--bad"}}).Check(); !res.OK() {
+			t.Fatalf("期望只检查存在性, 实际为 %v", res.Err)
+		}
+	})
+
+	t.Run("按路径和修改时间缓存", func(t *testing.T) {
+		cached := writeFakeTool(t, dir, "fake-cached", "v1.0.0")
+		info, err := os.Stat(cached)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res, _ := Require(cached, ""); res.Version.String() != "1.0.0" {
+			t.Fatalf("期望版本 1.0.0, 实际为 %s", res.Version)
+		}
+
+		// 修改时间不变时使用缓存
+		writeFakeTool(t, dir, "fake-cached", "v2.0.0")
+		if err := os.Chtimes(cached, info.ModTime(), info.ModTime()); err != nil {
+			t.Fatal(err)
+		}
+		if res, _ := Require(cached, ""); res.Version.String() != "1.0.0" {
+			t.Fatalf("期望使用缓存版本 1.0.0, 实际为 %s", res.Version)
+		}
+
+		// 修改时间变化后重新探测
+		mtime := info.ModTime().Add(1e9)
+		if err := os.Chtimes(cached, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		if res, _ := Require(cached, ""); res.Version.String() != "2.0.0" {
+			t.Fatalf("期望重新探测到 2.0.0, 实际为 %s", res.Version)
+		}
+	})
+
+	t.Run("批量检查", func(t *testing.T) {
+		report, err := RequireAll(
+			Requirement{Name: tool, Constraint: ">=2.30"},
+			Requirement{Name: tool, Constraint: ">=3"},
+			Requirement{Name: "shellx-no-such-tool"},
+		)
+		if err == nil || report.OK() {
+			t.Fatal("期望检查失败")
+		}
+		if len(report.Results) != 3 || !report.Results[0].OK() {
+			t.Fatalf("结果不正确: %v", report)
+		}
+		if failed := report.Failed(); len(failed) != 2 {
+			t.Fatalf("期望 2 个失败结果, 实际为 %d", len(failed))
+		}
+		if !strings.Contains(report.String(), "FAIL") {
+			t.Fatalf("报告中应包含失败项: %s", report)
+		}
+	})
+}
@@ -0,0 +1,338 @@
+// Package shellx 脚本执行模块
+// 本文件实现了通过系统shell执行多行脚本的功能，包括：
+//   - NewScriptStr: 执行脚本内容，内容写入私有临时目录中的文件，执行结束后删除
+//   - NewScriptFile: 执行脚本文件
+//   - 解释器选择：优先使用 shebang (#!) 指定的解释器，没有 shebang 时使用命令的 ShellType
+//   - 位置参数：脚本参数作为 $1..$n (sh/bash) 或 $args (pwsh) 传入
+package shellx
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// scriptName 脚本内容命令的名称
+const scriptName = "<script>"
+
+// scriptSource 脚本来源
+type scriptSource struct {
+	body   string // 脚本内容 (NewScriptStr)
+	path   string // 脚本文件 (NewScriptFile)
+	tmpDir string // 执行期间使用的私有临时目录
+}
+
+// NewScriptStr 创建执行脚本内容的命令对象
+//
+// 参数：
+//   - body: 脚本内容, 可以包含多行
+//   - args: 位置参数, 在脚本中通过 $1..$n (sh/bash) 或 $args (pwsh) 获取
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 脚本以 shebang (如 #!/usr/bin/env bash) 开头时使用其指定的解释器, 否则使用 WithShell 设置的shell
+//   - 脚本内容写入权限为0700的私有临时目录中, 执行结束后删除; 标准输入保留给命令使用
+//   - 临时文件名使用shell的脚本扩展名 (如 pwsh 为 .ps1), $0 为临时文件路径
+//   - 支持 WithShellOptions、WithLoginShell、WithInteractive, 需要前置语句的选项会写入脚本开头
+//
+// 示例:
+//
+//	shellx.NewScriptStr(`
+//	for f in "$@"; do
+//		echo "processing $f"
+//	done
+//	`, "a.txt", "b.txt").WithShell(shellx.ShellBash).Exec()
+func NewScriptStr(body string, args ...string) *Command {
+	if body == "" {
+		panic("script body must not be empty")
+	}
+
+	c := NewCmd(scriptName, args...)
+	c.script = &scriptSource{body: body}
+	return c
+}
+
+// NewScriptFile 创建执行脚本文件的命令对象
+//
+// 参数：
+//   - path: 脚本文件路径
+//   - args: 位置参数, 在脚本中通过 $1..$n (sh/bash) 或 $args (pwsh) 获取
+//
+// 返回：
+//   - *Command: 命令对象
+//
+// 注意:
+//   - 脚本以 shebang 开头时使用其指定的解释器, 否则使用 WithShell 设置的shell, 脚本文件不需要可执行权限
+//   - pwsh/powershell 要求脚本文件使用 .ps1 扩展名, cmd 要求使用 .bat 或 .cmd 扩展名
+//   - 需要前置语句的选项 (如 sh 的 PipeFail、pwsh 的 ErrExit) 会将脚本复制到临时文件执行, 此时 $0 为临时文件路径
+func NewScriptFile(path string, args ...string) *Command {
+	if path == "" {
+		panic("script path must not be empty")
+	}
+
+	c := NewCmd(path, args...)
+	c.script = &scriptSource{path: path}
+	return c
+}
+
+// scriptCommand 返回执行脚本的可执行文件和参数
+//
+// 返回:
+//   - string: 解释器
+//   - []string: 参数
+//   - error: 读取脚本、查找解释器或写入临时文件失败时返回错误
+func (c *Command) scriptCommand() (string, []string, error) {
+	_, interp, args, err := c.scriptArgv(c.script.file)
+	return interp, args, err
+}
+
+// scriptStr 返回执行脚本的完整参数列表, 用于 CmdStr
+//
+// 返回:
+//   - string: 参数列表, 每个参数按shell的规则引用, 脚本内容显示为 <script>
+//   - bool: 是否成功确定解释器
+//
+// 注意:
+//   - 不会创建临时文件, 执行前后返回相同的结果
+func (c *Command) scriptStr() (string, bool) {
+	s := c.script
+	shell, interp, args, err := c.scriptArgv(func(string, string, string) (string, error) {
+		if s.path != "" {
+			return s.path, nil
+		}
+		return scriptName, nil
+	})
+	if err != nil {
+		return "", false
+	}
+
+	argv := append([]string{interp}, args...)
+	for i, arg := range argv {
+		argv[i] = shell.Quote(arg)
+	}
+	return strings.Join(argv, " "), true
+}
+
+// scriptArgv 确定执行脚本的解释器和参数
+//
+// 参数:
+//   - file: 返回交给解释器执行的脚本文件路径, 参见 scriptSource.file
+//
+// 返回:
+//   - ShellType: 用于引用参数的shell, 解释器不是已注册的shell时为命令的shell
+//   - string: 解释器
+//   - []string: 参数
+//   - error: 读取脚本、查找解释器或获取脚本文件失败时返回错误
+func (c *Command) scriptArgv(file func(dir, ext, prelude string) (string, error)) (ShellType, string, []string, error) {
+	s := c.script
+	content := []byte(s.body)
+	if s.path != "" {
+		var err error
+		if content, err = readShebangLine(s.resolve(c.dir)); err != nil {
+			return ShellNone, "", nil, err
+		}
+	}
+
+	// shebang 指定的解释器不是已注册的shell时直接使用该解释器执行
+	interp, interpArgs, hasShebang := parseShebang(content, c.envs)
+	shell := c.shellType
+	if shell == ShellNone {
+		shell = ShellDef1
+	}
+	if hasShebang {
+		t, ok := LookupShell(filepath.Base(interp))
+		if !ok {
+			if c.shellInv.enabled() {
+				return ShellNone, "", nil, fmt.Errorf("%w: interpreter %s is not a registered shell", ErrShellOptionUnsupported, interp)
+			}
+			path, err := file(c.dir, "", "")
+			if err != nil {
+				return ShellNone, "", nil, err
+			}
+			return shell, interp, slices.Concat(interpArgs, []string{path}, c.args), nil
+		}
+		shell = t
+	}
+
+	spec, flags, prelude, err := shell.invocation(c.shellInv)
+	if err != nil {
+		return ShellNone, "", nil, err
+	}
+	if !hasShebang {
+		if interp, err = shell.Path(); err != nil {
+			return ShellNone, "", nil, err
+		}
+	}
+
+	path, err := file(c.dir, spec.ScriptExt, prelude)
+	if err != nil {
+		return ShellNone, "", nil, err
+	}
+
+	// 保留 ShellSpec.Args 中执行命令字符串的标志之前的参数 (如 busybox 的 ash)
+	var args []string
+	if n := len(spec.Args); n > 0 && !hasShebang {
+		args = append(args, spec.Args[:n-1]...)
+	}
+	args = append(args, flags...)
+	args = append(args, interpArgs...)
+	if spec.FileFlag != "" {
+		args = append(args, spec.FileFlag)
+	}
+	args = append(args, path)
+	return shell, interp, append(args, c.args...), nil
+}
+
+// file 返回交给解释器执行的脚本文件路径
+//
+// 参数:
+//   - dir: 命令的工作目录
+//   - ext: 临时文件扩展名
+//   - prelude: 写入脚本开头的前置语句
+//
+// 返回:
+//   - string: 脚本文件路径
+//   - error: 写入临时文件失败时返回错误
+//
+// 注意:
+//   - 脚本文件不需要前置语句时直接返回其路径, 否则写入私有临时目录
+func (s *scriptSource) file(dir, ext, prelude string) (string, error) {
+	if s.path != "" && prelude == "" {
+		return s.path, nil
+	}
+
+	content := []byte(s.body)
+	if s.path != "" {
+		path := s.resolve(dir)
+		var err error
+		if content, err = os.ReadFile(path); err != nil {
+			return "", err
+		}
+		if ext == "" {
+			ext = filepath.Ext(path)
+		}
+	}
+	if prelude != "" {
+		content = insertPrelude(content, prelude)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "shellx-script-")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(tmpDir, "script"+ext)
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		_ = os.RemoveAll(tmpDir)
+		return "", err
+	}
+	s.tmpDir = tmpDir
+	return path, nil
+}
+
+// resolve 返回脚本文件相对于命令工作目录的路径
+func (s *scriptSource) resolve(dir string) string {
+	if filepath.IsAbs(s.path) || dir == "" {
+		return s.path
+	}
+	return filepath.Join(dir, s.path)
+}
+
+// cleanup 删除执行期间使用的临时目录
+func (s *scriptSource) cleanup() {
+	if s == nil || s.tmpDir == "" {
+		return
+	}
+	_ = os.RemoveAll(s.tmpDir)
+	s.tmpDir = ""
+}
+
+// readShebangLine 读取脚本文件的第一行
+func readShebangLine(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	line, err := bufio.NewReader(io.LimitReader(f, 1024)).ReadBytes('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	return line, nil
+}
+
+// parseShebang 解析脚本开头的 shebang
+//
+// 参数:
+//   - content: 脚本内容
+//   - envs: 命令的环境变量, 其中包含 PATH 时在该 PATH 中查找解释器, 否则使用当前进程的 PATH
+//
+// 返回:
+//   - string: 解释器路径, 不是存在的绝对路径时使用PATH中的同名程序
+//   - []string: 解释器参数, 按空白分割
+//   - bool: 是否存在 shebang
+//
+// 注意:
+//   - 支持 #!/usr/bin/env NAME 和 #!/usr/bin/env -S NAME ARGS 形式
+func parseShebang(content []byte, envs []string) (string, []string, bool) {
+	line, _, _ := bytes.Cut(content, []byte("\n"))
+	rest, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("#!"))
+	if !ok {
+		return "", nil, false
+	}
+
+	fields := strings.Fields(string(rest))
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+	interp, args := fields[0], fields[1:]
+
+	// env 在PATH中查找解释器
+	if filepath.Base(interp) == "env" {
+		if len(args) > 0 && args[0] == "-S" {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			return "", nil, false
+		}
+		interp, args = args[0], args[1:]
+	}
+
+	// 只检查绝对路径是否存在, env 指定的名称和相对路径始终在PATH中查找,
+	// 绝对路径不存在时 (如在Windows上执行 #!/bin/bash 脚本) 也使用PATH中的同名程序
+	if filepath.IsAbs(interp) && runtime.GOOS != "windows" {
+		if _, err := os.Stat(interp); err == nil {
+			return interp, args, true
+		}
+	}
+	if path, err := findInterp(filepath.Base(interp), envs); err == nil {
+		interp = path
+	}
+	return interp, args, true
+}
+
+// findInterp 在命令运行时使用的 PATH 中查找解释器, 与 resolveExecPath 的规则一致
+func findInterp(name string, envs []string) (string, error) {
+	if pathList, ok := envPath(envs); ok {
+		return FindIn(name, pathList)
+	}
+	return FindCmd(name)
+}
+
+// insertPrelude 将前置语句插入脚本开头, 保留 shebang 行
+func insertPrelude(content []byte, prelude string) []byte {
+	var shebang []byte
+	if bytes.HasPrefix(content, []byte("#!")) {
+		line, rest, _ := bytes.Cut(content, []byte("\n"))
+		shebang, content = append(slices.Clip(line), '\n'), rest
+	}
+	return slices.Concat(shebang, []byte(prelude+"\n"), content)
+}
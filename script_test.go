@@ -0,0 +1,262 @@
+package shellx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestParseShebang 测试shebang解析
+func TestParseShebang(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		interp  string
+		args    []string
+		ok      bool
+	}{
+		{name: "绝对路径", content: "#!/bin/sh\necho hi", interp: "/bin/sh", ok: true},
+		{name: "带参数", content: "#!/bin/sh -e\n", interp: "/bin/sh", args: []string{"-e"}, ok: true},
+		{name: "env", content: "#!/usr/bin/env sh\n", interp: "sh", ok: true},
+		{name: "env -S", content: "#!/usr/bin/env -S sh -e -u\n", interp: "sh", args: []string{"-e", "-u"}, ok: true},
+		{name: "没有shebang", content: "echo hi\n"},
+		{name: "空shebang", content: "#!\n"},
+		{name: "env缺少解释器", content: "#!/usr/bin/env\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			interp, args, ok := parseShebang([]byte(tt.content), nil)
+			if ok != tt.ok {
+				t.Fatalf("期望 ok=%v, 实际为 %v", tt.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			// 通过env或不存在的路径指定时解析为PATH中的程序
+			if filepath.Base(interp) != filepath.Base(tt.interp) {
+				t.Errorf("期望解释器 %q, 实际为 %q", tt.interp, interp)
+			}
+			if strings.Join(args, " ") != strings.Join(tt.args, " ") {
+				t.Errorf("期望参数 %q, 实际为 %q", tt.args, args)
+			}
+		})
+	}
+}
+
+// TestParseShebangRelative 测试env指定的名称不受当前工作目录中同名文件的影响
+func TestParseShebangRelative(t *testing.T) {
+	want, err := FindCmd("sh")
+	if err != nil {
+		t.Skip("sh不可用")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sh"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+
+	interp, _, ok := parseShebang([]byte("#!/usr/bin/env sh\n"), nil)
+	if !ok || interp != want {
+		t.Errorf("期望解释器 %q, 实际为 %q", want, interp)
+	}
+}
+
+// TestParseShebangEnvPath 测试在命令环境变量的PATH中查找env指定的解释器
+func TestParseShebangEnvPath(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用POSIX shell脚本")
+	}
+
+	dir := t.TempDir()
+	interp := filepath.Join(dir, "shellx-test-interp")
+	if err := os.WriteFile(interp, []byte("#!/bin/sh\necho \"interp $1\"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := NewScriptStr("#!/usr/bin/env shellx-test-interp\n").
+		WithEnv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH")).
+		ExecOutput()
+	if err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+	if !strings.HasPrefix(string(out), "interp ") {
+		t.Errorf("期望使用命令PATH中的解释器, 实际输出为 %q", out)
+	}
+}
+
+// TestInsertPrelude 测试前置语句插入位置
+func TestInsertPrelude(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "保留shebang", content: "#!/bin/sh\necho hi\n", want: "#!/bin/sh\nset -e\necho hi\n"},
+		{name: "没有shebang", content: "echo hi\n", want: "set -e\necho hi\n"},
+		{name: "只有shebang", content: "#!/bin/sh", want: "#!/bin/sh\nset -e\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(insertPrelude([]byte(tt.content), "set -e")); got != tt.want {
+				t.Errorf("期望 %q, 实际为 %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestScript 测试脚本执行
+func TestScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试使用POSIX shell脚本")
+	}
+
+	t.Run("位置参数", func(t *testing.T) {
+		out, err := NewScriptStr(`
+echo "$#"
+for a in "$@"; do
+	echo "[$a]"
+done
+`, "a b", "", "c").WithShell(ShellSh).ExecOutput()
+		if err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		want := "3\n[a b]\n[]\n[c]\n"
+		if string(out) != want {
+			t.Errorf("期望 %q, 实际为 %q", want, out)
+		}
+	})
+
+	t.Run("$0为脚本文件", func(t *testing.T) {
+		out, err := NewScriptStr(`basename "$0"`).WithShell(ShellSh).ExecOutput()
+		if err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		if got := strings.TrimSpace(string(out)); got != "script.sh" {
+			t.Errorf("期望 script.sh, 实际为 %q", got)
+		}
+	})
+
+	t.Run("shebang选择解释器", func(t *testing.T) {
+		if !ShellBash.Available() {
+			t.Skip("bash不可用")
+		}
+		// 数组是bash特有的语法, sh无法执行
+		out, err := NewScriptStr("#!/usr/bin/env bash\narr=(x y z)\necho \"${#arr[@]} $1\"\n", "ok").
+			WithShell(ShellSh).ExecOutput()
+		if err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		if got := strings.TrimSpace(string(out)); got != "3 ok" {
+			t.Errorf("期望 %q, 实际为 %q", "3 ok", got)
+		}
+	})
+
+	t.Run("非shell解释器", func(t *testing.T) {
+		out, err := NewScriptStr("#!/bin/cat\nhello\n").ExecOutput()
+		if err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		if !strings.Contains(string(out), "hello") {
+			t.Errorf("期望输出脚本内容, 实际为 %q", out)
+		}
+
+		_, err = NewScriptStr("#!/bin/cat\nhello\n").WithShellOptions(ErrExit).ExecOutput()
+		if !errors.Is(err, ErrShellOptionUnsupported) {
+			t.Errorf("期望 ErrShellOptionUnsupported, 实际为 %v", err)
+		}
+	})
+
+	t.Run("shell选项", func(t *testing.T) {
+		out, err := NewScriptStr("false\necho unreachable\n").WithShell(ShellSh).WithShellOptions(ErrExit).ExecOutput()
+		if err == nil {
+			t.Fatal("期望执行失败")
+		}
+		if strings.Contains(string(out), "unreachable") {
+			t.Errorf("ErrExit未生效: %q", out)
+		}
+
+		// sh的PipeFail使用前置语句写入脚本开头
+		cmd := NewScriptStr("false | true\n").WithShell(ShellSh).WithShellOptions(PipeFail)
+		err = cmd.Exec()
+		if NewCmdStr("set -o pipefail").WithShell(ShellSh).Exec() == nil && err == nil {
+			t.Error("期望PipeFail使管道失败")
+		}
+	})
+
+	t.Run("CmdStr包含解释器参数", func(t *testing.T) {
+		path, err := ShellSh.Path()
+		if err != nil {
+			t.Skip("sh不可用")
+		}
+		cmd := NewScriptStr("echo hi\n", "a b").WithShell(ShellSh).WithShellOptions(ErrExit)
+		want := path + " -e '<script>' 'a b'"
+		if got := cmd.CmdStr(); got != want {
+			t.Errorf("期望 %q, 实际为 %q", want, got)
+		}
+		if err := cmd.Exec(); err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		if got := cmd.CmdStr(); got != want {
+			t.Errorf("执行后期望 %q, 实际为 %q", want, got)
+		}
+	})
+
+	t.Run("脚本文件", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "run.sh")
+		if err := os.WriteFile(path, []byte("echo \"$(basename \"$0\") $1 $2\"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := NewScriptFile(path, "x", "y").WithShell(ShellSh).ExecOutput()
+		if err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		if got := strings.TrimSpace(string(out)); got != "run.sh x y" {
+			t.Errorf("期望 %q, 实际为 %q", "run.sh x y", got)
+		}
+	})
+
+	t.Run("相对路径和工作目录", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "run.sh"), []byte("#!/bin/sh\necho \"$1\"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		out, err := NewScriptFile("run.sh", "rel").WithWorkDir(dir).ExecOutput()
+		if err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		if got := strings.TrimSpace(string(out)); got != "rel" {
+			t.Errorf("期望 rel, 实际为 %q", got)
+		}
+	})
+
+	t.Run("执行后删除临时目录", func(t *testing.T) {
+		cmd := NewScriptStr(`dirname "$0"`).WithShell(ShellSh)
+		out, err := cmd.ExecOutput()
+		if err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		dir := strings.TrimSpace(string(out))
+		if !strings.Contains(filepath.Base(dir), "shellx-script-") {
+			t.Fatalf("期望在临时目录中执行, 实际为 %q", dir)
+		}
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("临时目录未删除: %v", err)
+		}
+	})
+
+	t.Run("脚本文件不存在", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing.sh")
+		err := NewScriptFile(path).Exec()
+		if err == nil || !strings.Contains(err.Error(), path) {
+			t.Errorf("期望包含脚本路径的错误, 实际为 %v", err)
+		}
+	})
+}
@@ -0,0 +1,285 @@
+// Package shellx shell注册模块
+// 本文件实现了可扩展的shell注册表，包括：
+//   - ShellSpec: shell的描述，包括可执行文件、执行命令字符串的参数、参数引用规则等
+//   - RegisterShell: 注册自定义shell或覆盖内置shell的配置，返回可用于 WithShell 的 ShellType
+//   - LookupShell: 按名称查找已注册的shell
+//   - 内置shell (sh、bash、pwsh、powershell、cmd、zsh、fish、dash、ksh、ash) 同样以注册表项的形式提供
+//
+// 使用未注册的 ShellType 执行命令时返回 ErrUnknownShell，不会启动进程。
+package shellx
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrUnknownShell 表示使用了未注册的shell类型
+	ErrUnknownShell = errors.New("unknown shell type")
+	// ErrInvalidShellSpec 表示shell描述不合法
+	ErrInvalidShellSpec = errors.New("invalid shell spec")
+)
+
+// ShellSpec shell描述
+type ShellSpec struct {
+	Name            string              // 注册名称, 如 "zsh"
+	Path            string              // 可执行文件名称或绝对路径, 为空时使用Name
+	Args            []string            // 位于命令字符串之前的参数, 最后一个应为执行命令字符串的标志, 为nil时使用 []string{"-c"}
+	QuoteFunc       func(string) string // 单个参数的引用函数, 为nil时使用POSIX单引号规则
+	ScriptExt       string              // 脚本文件扩展名, 如 ".sh", 为空表示不需要扩展名
+	LoginFlag       string              // 以登录shell启动的参数, 如 "-l", 为空表示不支持
+	InteractiveFlag string              // 以交互式shell启动的参数, 如 "-i", 为空表示不支持
+	VersionArgs     []string            // 获取版本信息的参数, 为空时使用 --version
+	FileFlag        string              // 执行脚本文件的标志, 如 pwsh 的 "-File", 为空表示脚本文件路径直接作为参数
+
+	// OptionFunc 将shell选项转换为启动参数和命令前置语句, 为nil时使用POSIX规则 (-e -u -x -o pipefail),
+	// 不支持的选项应返回包装了 ErrShellOptionUnsupported 的错误
+	OptionFunc func(opts []ShellOption) (flags []string, prelude string, err error)
+}
+
+// psVersionArgs PowerShell获取版本信息的参数
+var psVersionArgs = []string{"-NoProfile", "-Command", "$PSVersionTable.PSVersion.ToString()"}
+
+// shellRegistry shell注册表
+var shellRegistry = struct {
+	mu    sync.RWMutex
+	specs map[ShellType]ShellSpec
+	names map[string]ShellType
+	next  ShellType // 下一个自定义shell的类型值
+}{
+	specs: make(map[ShellType]ShellSpec),
+	names: make(map[string]ShellType),
+	next:  shellCustomStart,
+}
+
+func init() {
+	builtins := []struct {
+		t    ShellType
+		spec ShellSpec
+	}{
+		{ShellSh, ShellSpec{Name: "sh", ScriptExt: ".sh", LoginFlag: "-l", InteractiveFlag: "-i", OptionFunc: shOptions}},
+		{ShellBash, ShellSpec{Name: "bash", ScriptExt: ".sh", LoginFlag: "-l", InteractiveFlag: "-i"}},
+		{ShellPwsh, ShellSpec{Name: "pwsh", Args: []string{"-Command"}, QuoteFunc: quotePowerShell, ScriptExt: ".ps1", LoginFlag: "-Login", InteractiveFlag: "-Interactive", VersionArgs: psVersionArgs, FileFlag: "-File", OptionFunc: powerShellOptions}},
+		{ShellPowerShell, ShellSpec{Name: "powershell", Args: []string{"-Command"}, QuoteFunc: quotePowerShell, ScriptExt: ".ps1", VersionArgs: psVersionArgs, FileFlag: "-File", OptionFunc: powerShellOptions}},
+		{ShellCmd, ShellSpec{Name: "cmd", Args: []string{"/c"}, QuoteFunc: quoteCmd, ScriptExt: ".bat", VersionArgs: []string{"/c", "ver"}, FileFlag: "/c", OptionFunc: noOptions}},
+		{ShellZsh, ShellSpec{Name: "zsh", ScriptExt: ".zsh", LoginFlag: "-l", InteractiveFlag: "-i"}},
+		{ShellFish, ShellSpec{Name: "fish", QuoteFunc: quoteFish, ScriptExt: ".fish", LoginFlag: "-l", InteractiveFlag: "-i", OptionFunc: fishOptions}},
+		{ShellDash, ShellSpec{Name: "dash", ScriptExt: ".sh", LoginFlag: "-l", InteractiveFlag: "-i", OptionFunc: shOptions}},
+		{ShellKsh, ShellSpec{Name: "ksh", ScriptExt: ".sh", LoginFlag: "-l", InteractiveFlag: "-i"}},
+		{ShellAsh, ShellSpec{Name: "ash", Path: "busybox", Args: []string{"ash", "-c"}, ScriptExt: ".sh", LoginFlag: "-l", InteractiveFlag: "-i", VersionArgs: []string{"--help"}}},
+	}
+	for _, b := range builtins {
+		spec := b.spec.withDefaults()
+		shellRegistry.specs[b.t] = spec
+		shellRegistry.names[spec.Name] = b.t
+	}
+}
+
+// RegisterShell 注册shell
+//
+// 参数:
+//   - spec: shell描述
+//
+// 返回:
+//   - ShellType: shell类型, 可用于 WithShell
+//   - error: 名称为空或为保留名称时返回 ErrInvalidShellSpec
+//
+// 注意:
+//   - 名称已注册时覆盖原有配置并返回原有的shell类型, 可用于修改内置shell的路径
+//   - 覆盖配置只影响之后启动的命令
+//
+// 示例:
+//
+//	bash5, err := shellx.RegisterShell(shellx.ShellSpec{Name: "bash5", Path: "/opt/bin/bash5"})
+//	if err != nil {
+//		return err
+//	}
+//	shellx.NewCmdStr("echo ${BASH_VERSION}").WithShell(bash5).Exec()
+func RegisterShell(spec ShellSpec) (ShellType, error) {
+	if spec.Name == "" {
+		return 0, fmt.Errorf("%w: name is empty", ErrInvalidShellSpec)
+	}
+	if spec.Name == ShellNone.String() || spec.Name == "unknown" {
+		return 0, fmt.Errorf("%w: name %q is reserved", ErrInvalidShellSpec, spec.Name)
+	}
+	spec = spec.withDefaults()
+
+	shellRegistry.mu.Lock()
+	t, ok := shellRegistry.names[spec.Name]
+	if !ok {
+		t = shellRegistry.next
+		shellRegistry.next++
+		shellRegistry.names[spec.Name] = t
+	}
+	shellRegistry.specs[t] = spec
+	shellRegistry.mu.Unlock()
+
+	// 配置变化后重新检测
+	forgetShell(t)
+	return t, nil
+}
+
+// LookupShell 按名称查找已注册的shell
+//
+// 参数:
+//   - name: 注册名称
+//
+// 返回:
+//   - ShellType: shell类型
+//   - bool: 是否已注册
+func LookupShell(name string) (ShellType, bool) {
+	shellRegistry.mu.RLock()
+	defer shellRegistry.mu.RUnlock()
+
+	t, ok := shellRegistry.names[name]
+	return t, ok
+}
+
+// Spec 获取shell类型对应的描述
+//
+// 返回:
+//   - ShellSpec: shell描述, ShellDef1/ShellDef2/ShellAuto 返回实际使用的shell
+//   - error: 未注册或为ShellNone时返回 ErrUnknownShell
+func (s ShellType) Spec() (ShellSpec, error) {
+	s = s.resolve()
+
+	shellRegistry.mu.RLock()
+	spec, ok := shellRegistry.specs[s]
+	shellRegistry.mu.RUnlock()
+
+	if !ok {
+		return ShellSpec{}, fmt.Errorf("%w: %d", ErrUnknownShell, int(s))
+	}
+	spec.Args = append([]string(nil), spec.Args...)
+	spec.VersionArgs = append([]string(nil), spec.VersionArgs...)
+	return spec, nil
+}
+
+// Quote 按shell的引用规则引用单个参数
+//
+// 参数:
+//   - arg: 参数
+//
+// 返回:
+//   - string: 引用后的参数, 未注册的shell使用POSIX单引号规则
+func (s ShellType) Quote(arg string) string {
+	spec, err := s.Spec()
+	if err != nil {
+		return quotePOSIX(arg)
+	}
+	return spec.QuoteFunc(arg)
+}
+
+// resolve 将 ShellDef1/ShellDef2/ShellAuto 解析为实际使用的shell
+func (s ShellType) resolve() ShellType {
+	switch s {
+	case ShellAuto:
+		return autoShell()
+
+	case ShellDef1:
+		if runtime.GOOS == "windows" {
+			return ShellCmd
+		}
+		return ShellSh
+
+	case ShellDef2:
+		if runtime.GOOS == "windows" {
+			return ShellPowerShell
+		}
+		return ShellSh
+	}
+	return s
+}
+
+// command 返回通过shell执行命令字符串的可执行文件和参数
+//
+// 参数:
+//   - cmdStr: 命令字符串
+//   - inv: 启动配置
+//
+// 返回:
+//   - string: 可执行文件的绝对路径
+//   - []string: 参数
+//   - error: 未注册的shell返回 ErrUnknownShell, 不可用的shell返回 *ShellUnavailableError,
+//     不支持的配置返回 ErrShellOptionUnsupported
+func (s ShellType) command(cmdStr string, inv shellInvocation) (string, []string, error) {
+	s = s.resolve()
+	_, args, err := s.argv(cmdStr, inv)
+	if err != nil {
+		return "", nil, err
+	}
+	path, err := s.Path()
+	if err != nil {
+		return "", nil, err
+	}
+	return path, args, nil
+}
+
+// withDefaults 填充shell描述的默认值
+func (spec ShellSpec) withDefaults() ShellSpec {
+	if spec.Path == "" {
+		spec.Path = spec.Name
+	}
+	if spec.Args == nil {
+		spec.Args = []string{"-c"}
+	}
+	if spec.QuoteFunc == nil {
+		spec.QuoteFunc = quotePOSIX
+	}
+	if spec.OptionFunc == nil {
+		spec.OptionFunc = posixOptions
+	}
+	return spec
+}
+
+// shellSafe 判断参数是否无需引用
+func shellSafe(arg string) bool {
+	if arg == "" {
+		return false
+	}
+	for _, r := range arg {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case strings.ContainsRune("_@%+=:,./-", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// quotePOSIX 按POSIX shell规则引用参数
+func quotePOSIX(arg string) string {
+	if shellSafe(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// quoteFish 按fish规则引用参数, 单引号内的反斜杠和单引号需要转义
+func quoteFish(arg string) string {
+	if shellSafe(arg) {
+		return arg
+	}
+	r := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	return "'" + r.Replace(arg) + "'"
+}
+
+// quotePowerShell 按PowerShell规则引用参数, 单引号内的单引号需要重复
+func quotePowerShell(arg string) string {
+	if shellSafe(arg) {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", "''") + "'"
+}
+
+// quoteCmd 按cmd规则引用参数, 双引号内的双引号需要重复
+func quoteCmd(arg string) string {
+	if shellSafe(arg) {
+		return arg
+	}
+	return `"` + strings.ReplaceAll(arg, `"`, `""`) + `"`
+}
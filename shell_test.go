@@ -0,0 +1,211 @@
+package shellx
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestShellRegistry 测试shell注册表
+func TestShellRegistry(t *testing.T) {
+	t.Run("内置shell", func(t *testing.T) {
+		tests := []struct {
+			shell ShellType
+			name  string
+		}{
+			{ShellSh, "sh"},
+			{ShellBash, "bash"},
+			{ShellPwsh, "pwsh"},
+			{ShellPowerShell, "powershell"},
+			{ShellCmd, "cmd"},
+			{ShellZsh, "zsh"},
+			{ShellFish, "fish"},
+			{ShellDash, "dash"},
+			{ShellKsh, "ksh"},
+			{ShellAsh, "ash"},
+			{ShellNone, "none"},
+			{ShellType(-1), "unknown"},
+		}
+		for _, tt := range tests {
+			if got := tt.shell.String(); got != tt.name {
+				t.Errorf("期望 %s, 实际为 %s", tt.name, got)
+			}
+			if tt.shell == ShellNone || tt.name == "unknown" {
+				continue
+			}
+			if got, ok := LookupShell(tt.name); !ok || got != tt.shell {
+				t.Errorf("按名称 %s 查找期望 %d, 实际为 %d (%v)", tt.name, tt.shell, got, ok)
+			}
+		}
+
+		spec, err := ShellAsh.Spec()
+		if err != nil {
+			t.Fatalf("获取描述失败: %v", err)
+		}
+		if spec.Path != "busybox" || !slices.Equal(spec.Args, []string{"ash", "-c"}) {
+			t.Errorf("ash描述不正确: %+v", spec)
+		}
+
+		want := "sh"
+		if runtime.GOOS == "windows" {
+			want = "cmd"
+		}
+		if ShellDef1.String() != want {
+			t.Errorf("期望ShellDef1为 %s, 实际为 %s", want, ShellDef1)
+		}
+	})
+
+	t.Run("注册自定义shell", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("需要sh")
+		}
+		sh, err := exec.LookPath("sh")
+		if err != nil {
+			t.Skip("未找到sh")
+		}
+
+		custom, err := RegisterShell(ShellSpec{Name: "shellx-test-sh", Path: sh})
+		if err != nil {
+			t.Fatalf("注册失败: %v", err)
+		}
+		out, err := NewCmdStr("echo $0").WithShell(custom).ExecOutput()
+		if err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		if strings.TrimSpace(string(out)) != sh {
+			t.Errorf("期望输出 %s, 实际为 %q", sh, out)
+		}
+
+		// 重复注册覆盖配置并返回原有类型
+		again, err := RegisterShell(ShellSpec{Name: "shellx-test-sh", Path: sh, Args: []string{"-e", "-c"}})
+		if err != nil || again != custom {
+			t.Fatalf("期望返回原有类型 %d, 实际为 %d (%v)", custom, again, err)
+		}
+		if spec, _ := custom.Spec(); !slices.Equal(spec.Args, []string{"-e", "-c"}) {
+			t.Errorf("期望配置被覆盖, 实际为 %+v", spec)
+		}
+	})
+
+	t.Run("非法描述", func(t *testing.T) {
+		for _, name := range []string{"", "none", "unknown"} {
+			if _, err := RegisterShell(ShellSpec{Name: name}); !errors.Is(err, ErrInvalidShellSpec) {
+				t.Errorf("名称 %q 期望 ErrInvalidShellSpec, 实际为 %v", name, err)
+			}
+		}
+	})
+
+	t.Run("未注册的shell", func(t *testing.T) {
+		err := NewCmdStr("echo hi").WithShell(ShellType(9999)).Exec()
+		if !errors.Is(err, ErrUnknownShell) {
+			t.Fatalf("期望 ErrUnknownShell, 实际为 %v", err)
+		}
+	})
+}
+
+// TestShellQuote 测试shell参数引用
+func TestShellQuote(t *testing.T) {
+	tests := []struct {
+		shell ShellType
+		arg   string
+		want  string
+	}{
+		{ShellSh, "plain-arg_1.txt", "plain-arg_1.txt"},
+		{ShellSh, "", "''"},
+		{ShellSh, "it's here", `'it'\''s here'`},
+		{ShellFish, `a\b'c`, `'a\\b\'c'`},
+		{ShellPwsh, "it's", "'it''s'"},
+		{ShellCmd, `say "hi"`, `"say ""hi"""`},
+	}
+	for _, tt := range tests {
+		if got := tt.shell.Quote(tt.arg); got != tt.want {
+			t.Errorf("%s.Quote(%q) 期望 %s, 实际为 %s", tt.shell, tt.arg, tt.want, got)
+		}
+	}
+}
+
+// TestShellDetection 测试shell可用性检测
+func TestShellDetection(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("测试依赖Unix shell")
+	}
+
+	t.Run("可用的shell", func(t *testing.T) {
+		if !ShellSh.Available() {
+			t.Fatal("期望sh可用")
+		}
+		path, err := ShellSh.Path()
+		if err != nil || !filepath.IsAbs(path) {
+			t.Fatalf("期望绝对路径, 实际为 %q (%v)", path, err)
+		}
+	})
+
+	t.Run("不可用的shell", func(t *testing.T) {
+		missing, err := RegisterShell(ShellSpec{Name: "shellx-missing-sh", Path: "shellx-no-such-shell"})
+		if err != nil {
+			t.Fatalf("注册失败: %v", err)
+		}
+		if missing.Available() {
+			t.Fatal("期望shell不可用")
+		}
+
+		err = NewCmdStr("echo hi").WithShell(missing).Exec()
+		var unavailable *ShellUnavailableError
+		if !errors.As(err, &unavailable) || unavailable.Shell != missing {
+			t.Fatalf("期望 *ShellUnavailableError, 实际为 %v", err)
+		}
+		if !errors.Is(err, ErrShellUnavailable) || !errors.Is(err, exec.ErrNotFound) {
+			t.Fatalf("期望同时匹配 ErrShellUnavailable 和 exec.ErrNotFound, 实际为 %v", err)
+		}
+
+		// 覆盖配置后重新检测
+		sh, _ := ShellSh.Path()
+		if _, err := RegisterShell(ShellSpec{Name: "shellx-missing-sh", Path: sh}); err != nil {
+			t.Fatalf("注册失败: %v", err)
+		}
+		if !missing.Available() {
+			t.Fatal("覆盖配置后期望shell可用")
+		}
+	})
+
+	t.Run("自动选择", func(t *testing.T) {
+		t.Cleanup(ResetShellDetection)
+
+		t.Setenv("SHELL", "/bin/sh")
+		ResetShellDetection()
+		if got := ShellAuto.String(); got != "sh" {
+			t.Fatalf("期望根据$SHELL选择sh, 实际为 %s", got)
+		}
+
+		t.Setenv("SHELL", "/usr/bin/shellx-unregistered")
+		ResetShellDetection()
+		want := "sh"
+		if ShellBash.Available() {
+			want = "bash"
+		}
+		if got := ShellAuto.String(); got != want {
+			t.Fatalf("期望选择 %s, 实际为 %s", want, got)
+		}
+
+		out, err := NewCmdStr("echo auto").WithShell(ShellAuto).ExecOutput()
+		if err != nil || strings.TrimSpace(string(out)) != "auto" {
+			t.Fatalf("执行失败: %q (%v)", out, err)
+		}
+	})
+
+	t.Run("版本", func(t *testing.T) {
+		if !ShellBash.Available() {
+			t.Skip("未安装bash")
+		}
+		v, err := ShellBash.Version()
+		if err != nil {
+			t.Fatalf("获取版本失败: %v", err)
+		}
+		if v.Major < 3 {
+			t.Fatalf("bash版本不正确: %s", v)
+		}
+	})
+}
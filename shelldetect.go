@@ -0,0 +1,175 @@
+// Package shellx shell检测模块
+// 本文件提供了shell的可用性检测功能，包括：
+//   - ShellType.Path/Available: 检测shell的可执行文件是否存在，结果只检测一次并缓存
+//   - ShellType.Version: 探测shell的版本号
+//   - ShellAuto: 按优先级 ($SHELL、bash、sh) 自动选择第一个可用的shell
+//   - ShellUnavailableError: 选择的shell不可用时在启动进程前返回的错误
+package shellx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// ErrShellUnavailable 表示shell的可执行文件不存在
+var ErrShellUnavailable = errors.New("shell is not available")
+
+// ShellUnavailableError 表示选择的shell不可用
+type ShellUnavailableError struct {
+	Shell ShellType // shell类型
+	Path  string    // 查找的可执行文件
+	Err   error     // 查找错误
+}
+
+func (e *ShellUnavailableError) Error() string {
+	return fmt.Sprintf("shell %s is not available: %s: %v", e.Shell, e.Path, e.Err)
+}
+
+// Is 使 errors.Is(err, ErrShellUnavailable) 成立
+func (e *ShellUnavailableError) Is(target error) bool {
+	return target == ErrShellUnavailable
+}
+
+func (e *ShellUnavailableError) Unwrap() error {
+	return e.Err
+}
+
+// shellDetection shell可执行文件的检测结果
+type shellDetection struct {
+	path string // 可执行文件的绝对路径
+	err  error  // 检测错误
+}
+
+// shellDetect shell检测缓存
+var shellDetect struct {
+	paths sync.Map // ShellType -> shellDetection
+
+	mu       sync.Mutex
+	auto     ShellType // ShellAuto选择的shell
+	autoDone bool      // 是否已经完成选择
+}
+
+// ResetShellDetection 清空shell检测缓存
+//
+// 注意:
+//   - 安装或删除shell、修改PATH或$SHELL后调用, 下次使用时重新检测
+//   - RegisterShell 覆盖配置时会自动清空对应shell的缓存
+func ResetShellDetection() {
+	shellDetect.paths.Clear()
+
+	shellDetect.mu.Lock()
+	shellDetect.autoDone = false
+	shellDetect.mu.Unlock()
+}
+
+// forgetShell 清空指定shell的检测缓存
+func forgetShell(s ShellType) {
+	shellDetect.paths.Delete(s)
+
+	shellDetect.mu.Lock()
+	shellDetect.autoDone = false
+	shellDetect.mu.Unlock()
+}
+
+// Path 获取shell可执行文件的绝对路径
+//
+// 返回:
+//   - string: 可执行文件的绝对路径
+//   - error: 未注册时返回 ErrUnknownShell, 可执行文件不存在时返回 *ShellUnavailableError
+//
+// 注意:
+//   - 在当前进程的PATH中查找, 结果会被缓存, 参见 ResetShellDetection
+func (s ShellType) Path() (string, error) {
+	s = s.resolve()
+	if v, ok := shellDetect.paths.Load(s); ok {
+		d := v.(shellDetection)
+		return d.path, d.err
+	}
+
+	spec, err := s.Spec()
+	if err != nil {
+		return "", err
+	}
+
+	var d shellDetection
+	if d.path, err = FindCmd(spec.Path); err != nil {
+		d.err = &ShellUnavailableError{Shell: s, Path: spec.Path, Err: err}
+	}
+	shellDetect.paths.Store(s, d)
+	return d.path, d.err
+}
+
+// Available 判断shell是否可用
+//
+// 返回:
+//   - bool: 已注册且可执行文件存在时返回true
+func (s ShellType) Available() bool {
+	_, err := s.Path()
+	return err == nil
+}
+
+// Version 探测shell的版本号
+//
+// 返回:
+//   - SemVer: 版本号
+//   - error: shell不可用或无法从输出中提取版本号时返回错误
+//
+// 注意:
+//   - 使用 ShellSpec.VersionArgs 执行shell, 结果按可执行文件路径和修改时间缓存
+//   - sh、dash 等不提供版本信息的shell返回 ErrVersionNotFound
+func (s ShellType) Version() (SemVer, error) {
+	path, err := s.Path()
+	if err != nil {
+		return SemVer{}, err
+	}
+	spec, err := s.Spec()
+	if err != nil {
+		return SemVer{}, err
+	}
+
+	res, err := Requirement{Name: path, Args: spec.VersionArgs}.probe(path)
+	if err != nil {
+		return SemVer{}, err
+	}
+	return res.version, nil
+}
+
+// autoShell 返回 ShellAuto 选择的shell
+//
+// 注意:
+//   - 依次尝试$SHELL对应的已注册shell、bash、sh (Windows上为pwsh、powershell、cmd), 选择第一个可用的
+//   - 都不可用时返回最后一个候选, 执行时会返回 *ShellUnavailableError
+func autoShell() ShellType {
+	shellDetect.mu.Lock()
+	defer shellDetect.mu.Unlock()
+
+	if shellDetect.autoDone {
+		return shellDetect.auto
+	}
+
+	var candidates []ShellType
+	if runtime.GOOS == "windows" {
+		candidates = []ShellType{ShellPwsh, ShellPowerShell, ShellCmd}
+	} else {
+		if env := os.Getenv("SHELL"); env != "" {
+			if s, ok := LookupShell(filepath.Base(env)); ok {
+				candidates = append(candidates, s)
+			}
+		}
+		candidates = append(candidates, ShellBash, ShellSh)
+	}
+
+	shellDetect.auto = candidates[len(candidates)-1]
+	for _, s := range candidates {
+		if s.Available() {
+			shellDetect.auto = s
+			break
+		}
+	}
+	shellDetect.autoDone = true
+	return shellDetect.auto
+}
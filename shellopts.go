@@ -0,0 +1,264 @@
+// Package shellx shell选项模块
+// 本文件实现了shell严格模式等选项的转换，包括：
+//   - ShellOption: ErrExit、NoUnset、PipeFail、XTrace 等shell选项
+//   - 按shell类型将选项转换为启动参数或命令前置语句，如 bash 的 -e -u -o pipefail，
+//     PowerShell 的 $ErrorActionPreference='Stop'
+//   - 登录shell (-l) 和交互式shell (-i) 参数
+//
+// 最终的参数列表可以通过 Command.CmdStr 查看。
+package shellx
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrShellOptionUnsupported 表示shell不支持指定的选项
+var ErrShellOptionUnsupported = errors.New("shell option not supported")
+
+// ShellOption shell选项
+type ShellOption int
+
+const (
+	ErrExit  ShellOption = iota // 命令失败时立即退出 (set -e)
+	NoUnset                     // 使用未定义的变量时报错 (set -u)
+	PipeFail                    // 管道中任一命令失败时整个管道失败 (set -o pipefail)
+	XTrace                      // 执行前打印每条命令 (set -x)
+)
+
+// String 返回shell选项的字符串表示
+func (o ShellOption) String() string {
+	switch o {
+	case ErrExit:
+		return "errexit"
+
+	case NoUnset:
+		return "nounset"
+
+	case PipeFail:
+		return "pipefail"
+
+	case XTrace:
+		return "xtrace"
+
+	default:
+		return fmt.Sprintf("ShellOption(%d)", int(o))
+	}
+}
+
+// shellInvocation shell的启动配置
+type shellInvocation struct {
+	opts        []ShellOption // shell选项
+	login       bool          // 是否以登录shell启动
+	interactive bool          // 是否以交互式shell启动
+}
+
+// enabled 判断是否设置了任何启动配置
+func (inv shellInvocation) enabled() bool {
+	return len(inv.opts) > 0 || inv.login || inv.interactive
+}
+
+// invocation 将启动配置转换为shell的启动参数和命令前置语句
+//
+// 参数:
+//   - inv: 启动配置
+//
+// 返回:
+//   - ShellSpec: shell描述
+//   - []string: 登录、交互和选项参数
+//   - string: 命令前置语句, 不需要时为空
+//   - error: 未注册的shell返回 ErrUnknownShell, 不支持的配置返回 ErrShellOptionUnsupported
+func (s ShellType) invocation(inv shellInvocation) (ShellSpec, []string, string, error) {
+	s = s.resolve()
+	spec, err := s.Spec()
+	if err != nil {
+		return ShellSpec{}, nil, "", err
+	}
+
+	var flags []string
+	if inv.login {
+		if spec.LoginFlag == "" {
+			return ShellSpec{}, nil, "", fmt.Errorf("%w: %s does not support login mode", ErrShellOptionUnsupported, s)
+		}
+		flags = append(flags, spec.LoginFlag)
+	}
+	if inv.interactive {
+		if spec.InteractiveFlag == "" {
+			return ShellSpec{}, nil, "", fmt.Errorf("%w: %s does not support interactive mode", ErrShellOptionUnsupported, s)
+		}
+		flags = append(flags, spec.InteractiveFlag)
+	}
+
+	var prelude string
+	if len(inv.opts) > 0 {
+		var optFlags []string
+		if optFlags, prelude, err = spec.OptionFunc(inv.opts); err != nil {
+			return ShellSpec{}, nil, "", fmt.Errorf("%s: %w", s, err)
+		}
+		flags = append(flags, optFlags...)
+	}
+	return spec, flags, prelude, nil
+}
+
+// argv 返回通过shell执行命令字符串的可执行文件和参数
+//
+// 参数:
+//   - cmdStr: 命令字符串
+//   - inv: 启动配置
+//
+// 返回:
+//   - string: ShellSpec.Path
+//   - []string: 参数, 登录、交互和选项参数插入在 ShellSpec.Args 的最后一个参数之前
+//   - error: 未注册的shell返回 ErrUnknownShell, 不支持的配置返回 ErrShellOptionUnsupported
+func (s ShellType) argv(cmdStr string, inv shellInvocation) (string, []string, error) {
+	spec, flags, prelude, err := s.invocation(inv)
+	if err != nil {
+		return "", nil, err
+	}
+	if prelude != "" {
+		cmdStr = prelude + "; " + cmdStr
+	}
+
+	// 选项参数需要位于执行命令字符串的标志 (如 -c) 之前
+	args := spec.Args
+	if n := len(args); n > 0 {
+		args = slices.Concat(args[:n-1], flags, args[n-1:])
+	} else {
+		args = flags
+	}
+	return spec.Path, append(args, cmdStr), nil
+}
+
+// invocationStr 返回通过带有启动配置的shell执行命令或脚本时的完整参数列表
+//
+// 返回:
+//   - string: 参数列表, 每个参数按shell的规则引用
+//   - bool: 命令是否通过带有启动配置的shell执行
+//
+// 注意:
+//   - 可执行文件使用解析后的绝对路径, shell不可用时使用 ShellSpec.Path
+func (c *Command) invocationStr() (string, bool) {
+	if !c.shellInv.enabled() {
+		return "", false
+	}
+	if c.script != nil {
+		return c.scriptStr()
+	}
+	if c.shellType == ShellNone {
+		return "", false
+	}
+
+	var argv []string
+	if c.execCmd != nil {
+		argv = append([]string{c.execCmd.Path}, c.execCmd.Args[1:]...)
+	} else {
+		name, args, err := c.shellType.command(c.getCmdStr(), c.shellInv)
+		if err != nil {
+			if name, args, err = c.shellType.argv(c.getCmdStr(), c.shellInv); err != nil {
+				return "", false
+			}
+		}
+		argv = append([]string{name}, args...)
+	}
+
+	shell := c.shellType.resolve()
+	for i, arg := range argv {
+		argv[i] = shell.Quote(arg)
+	}
+	return strings.Join(argv, " "), true
+}
+
+// optionSet 去重后的shell选项集合
+func optionSet(opts []ShellOption) map[ShellOption]bool {
+	set := make(map[ShellOption]bool, len(opts))
+	for _, o := range opts {
+		set[o] = true
+	}
+	return set
+}
+
+// posixOptions 将选项转换为POSIX shell的启动参数
+func posixOptions(opts []ShellOption) ([]string, string, error) {
+	set := optionSet(opts)
+
+	var flags []string
+	if set[ErrExit] {
+		flags = append(flags, "-e")
+	}
+	if set[NoUnset] {
+		flags = append(flags, "-u")
+	}
+	if set[XTrace] {
+		flags = append(flags, "-x")
+	}
+	if set[PipeFail] {
+		flags = append(flags, "-o", "pipefail")
+	}
+	return flags, "", unknownOptions(set)
+}
+
+// shOptions 将选项转换为sh/dash的启动参数
+//
+// 注意:
+//   - 旧版本的dash不支持pipefail, 作为参数传入会导致shell无法启动, 因此使用前置语句在支持时启用
+func shOptions(opts []ShellOption) ([]string, string, error) {
+	rest := slices.DeleteFunc(slices.Clone(opts), func(o ShellOption) bool { return o == PipeFail })
+	flags, _, err := posixOptions(rest)
+	if err != nil || len(rest) == len(opts) {
+		return flags, "", err
+	}
+	return flags, "(set -o pipefail) 2>/dev/null && set -o pipefail", nil
+}
+
+// powerShellOptions 将选项转换为PowerShell的前置语句
+func powerShellOptions(opts []ShellOption) ([]string, string, error) {
+	set := optionSet(opts)
+
+	var prelude []string
+	if set[ErrExit] {
+		prelude = append(prelude, "$ErrorActionPreference='Stop'")
+	}
+	if set[NoUnset] {
+		prelude = append(prelude, "Set-StrictMode -Version Latest")
+	}
+	if set[PipeFail] {
+		// PowerShell 7.3+ 中外部命令以非0退出码结束时按 $ErrorActionPreference 处理
+		prelude = append(prelude, "$PSNativeCommandUseErrorActionPreference=$true")
+	}
+	if set[XTrace] {
+		prelude = append(prelude, "Set-PSDebug -Trace 1")
+	}
+	return nil, strings.Join(prelude, "; "), unknownOptions(set)
+}
+
+// fishOptions 将选项转换为fish的前置语句, fish只支持XTrace
+func fishOptions(opts []ShellOption) ([]string, string, error) {
+	set := optionSet(opts)
+	for _, o := range [...]ShellOption{ErrExit, NoUnset, PipeFail} {
+		if set[o] {
+			return nil, "", fmt.Errorf("%w: %s", ErrShellOptionUnsupported, o)
+		}
+	}
+
+	if set[XTrace] {
+		return nil, "set fish_trace 1", unknownOptions(set)
+	}
+	return nil, "", unknownOptions(set)
+}
+
+// noOptions 用于不支持任何选项的shell (如cmd)
+func noOptions(opts []ShellOption) ([]string, string, error) {
+	return nil, "", fmt.Errorf("%w: %s", ErrShellOptionUnsupported, opts[0])
+}
+
+// unknownOptions 检查是否存在未定义的选项
+func unknownOptions(set map[ShellOption]bool) error {
+	for o := range set {
+		if o < ErrExit || o > XTrace {
+			return fmt.Errorf("%w: %s", ErrShellOptionUnsupported, o)
+		}
+	}
+	return nil
+}
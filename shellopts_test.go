@@ -0,0 +1,153 @@
+package shellx
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// TestShellOptionsArgv 测试shell选项转换后的参数列表
+func TestShellOptionsArgv(t *testing.T) {
+	tests := []struct {
+		name  string
+		cmd   *Command
+		shell ShellType // 解释器, ShellNone表示命令字符串保持原样
+		want  string    // 解释器之后的参数
+	}{
+		{
+			name:  "bash严格模式",
+			cmd:   NewCmdStr("echo hi").WithShell(ShellBash).WithShellOptions(ErrExit, NoUnset, PipeFail),
+			shell: ShellBash,
+			want:  "-e -u -o pipefail -c 'echo hi'",
+		},
+		{
+			name:  "sh的pipefail使用前置语句",
+			cmd:   NewCmdStr("echo hi").WithShell(ShellSh).WithShellOptions(PipeFail, XTrace),
+			shell: ShellSh,
+			want:  "-x -c '(set -o pipefail) 2>/dev/null && set -o pipefail; echo hi'",
+		},
+		{
+			name:  "登录和交互式",
+			cmd:   NewCmdStr("echo hi").WithShell(ShellZsh).WithLoginShell().WithInteractive().WithShellOptions(ErrExit),
+			shell: ShellZsh,
+			want:  "-l -i -e -c 'echo hi'",
+		},
+		{
+			name:  "选项位于命令标志之前",
+			cmd:   NewCmdStr("echo hi").WithShell(ShellAsh).WithShellOptions(ErrExit),
+			shell: ShellAsh,
+			want:  "ash -e -c 'echo hi'",
+		},
+		{
+			name:  "PowerShell",
+			cmd:   NewCmdStr("Get-Item x").WithShell(ShellPwsh).WithShellOptions(ErrExit, NoUnset),
+			shell: ShellPwsh,
+			want:  "-Command '$ErrorActionPreference=''Stop''; Set-StrictMode -Version Latest; Get-Item x'",
+		},
+		{
+			name:  "未设置选项时保持原样",
+			cmd:   NewCmdStr("echo hi").WithShell(ShellBash),
+			shell: ShellNone,
+			want:  "echo hi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.want
+			if tt.shell != ShellNone {
+				// 解释器使用解析后的绝对路径, 不可用时使用 ShellSpec.Path
+				path, err := tt.shell.Path()
+				if err != nil {
+					spec, _ := tt.shell.Spec()
+					path = spec.Path
+				}
+				want = tt.shell.Quote(path) + " " + want
+			}
+			if got := tt.cmd.CmdStr(); got != want {
+				t.Errorf("期望 %q, 实际为 %q", want, got)
+			}
+		})
+	}
+}
+
+// TestShellOptionsCmdStrStable 测试执行前后的命令字符串一致
+func TestShellOptionsCmdStrStable(t *testing.T) {
+	if !ShellSh.Available() {
+		t.Skip("sh不可用")
+	}
+
+	cmd := NewCmdStr("echo 'a b'").WithShell(ShellSh).WithShellOptions(ErrExit)
+	before := cmd.CmdStr()
+	if err := cmd.Exec(); err != nil {
+		t.Fatalf("执行失败: %v", err)
+	}
+	if after := cmd.CmdStr(); after != before {
+		t.Errorf("执行前后的命令字符串不一致: %q != %q", before, after)
+	}
+}
+
+// TestShellOptionsUnsupported 测试shell不支持的选项
+func TestShellOptionsUnsupported(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *Command
+	}{
+		{"cmd不支持选项", NewCmdStr("dir").WithShell(ShellCmd).WithShellOptions(ErrExit)},
+		{"fish不支持errexit", NewCmdStr("ls").WithShell(ShellFish).WithShellOptions(ErrExit)},
+		{"powershell不支持登录", NewCmdStr("ls").WithShell(ShellPowerShell).WithLoginShell()},
+		{"未定义的选项", NewCmdStr("ls").WithShell(ShellBash).WithShellOptions(ShellOption(42))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cmd.Exec(); !errors.Is(err, ErrShellOptionUnsupported) {
+				t.Fatalf("期望 ErrShellOptionUnsupported, 实际为 %v", err)
+			}
+		})
+	}
+}
+
+// TestShellOptionsExec 测试shell选项的实际效果
+func TestShellOptionsExec(t *testing.T) {
+	if runtime.GOOS == "windows" || !ShellBash.Available() {
+		t.Skip("需要bash")
+	}
+
+	t.Run("ErrExit", func(t *testing.T) {
+		out, err := NewCmdStr("false; echo after").WithShell(ShellBash).WithShellOptions(ErrExit).ExecOutput()
+		if err == nil || strings.Contains(string(out), "after") {
+			t.Fatalf("期望在失败处退出, 实际输出 %q (%v)", out, err)
+		}
+	})
+
+	t.Run("NoUnset", func(t *testing.T) {
+		err := NewCmdStr("echo $SHELLX_UNDEFINED_VARIABLE").WithShell(ShellBash).WithShellOptions(NoUnset).Exec()
+		if err == nil {
+			t.Fatal("期望使用未定义变量时失败")
+		}
+	})
+
+	t.Run("PipeFail", func(t *testing.T) {
+		if err := NewCmdStr("false | true").WithShell(ShellBash).Exec(); err != nil {
+			t.Fatalf("未启用pipefail时期望成功, 实际为 %v", err)
+		}
+		if err := NewCmdStr("false | true").WithShell(ShellBash).WithShellOptions(PipeFail).Exec(); err == nil {
+			t.Fatal("启用pipefail时期望失败")
+		}
+	})
+
+	t.Run("sh前置语句", func(t *testing.T) {
+		out, err := NewCmdStr("echo ok").WithShell(ShellSh).WithShellOptions(ErrExit, PipeFail).ExecOutput()
+		if err != nil || strings.TrimSpace(string(out)) != "ok" {
+			t.Fatalf("执行失败: %q (%v)", out, err)
+		}
+	})
+
+	t.Run("登录shell", func(t *testing.T) {
+		if err := NewCmdStr("shopt -q login_shell").WithShell(ShellBash).WithLoginShell().Exec(); err != nil {
+			t.Fatalf("期望以登录shell启动, 实际为 %v", err)
+		}
+	})
+}
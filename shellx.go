@@ -28,6 +28,7 @@
 // 核心组件：
 //   - Command: 命令对象，集配置、构建、执行于一体
 //   - ShellType: Shell类型枚举，支持多种shell
+//   - Executor: 执行器接口，默认为OSExecutor，测试时可替换为 shellxtest.FakeExecutor
 //
 // 基本用法：
 //
@@ -0,0 +1,470 @@
+// Package shellxtest 提供了 shellx 的测试辅助工具。
+//
+// FakeExecutor 实现了 shellx.Executor 接口，不会真正创建进程，
+// 而是根据预先设置的期望返回脚本化的标准输出、标准错误、退出码、延迟或错误，
+// 并记录每一次调用，便于在单元测试中进行断言。
+//
+// 基本用法:
+//
+//	fake := shellxtest.NewFakeExecutor(t)
+//	fake.Expect("git").Args(`^status`).Stdout("clean\n")
+//	fake.ExpectCmd("make build").ExitCode(2).Stderr("build failed\n")
+//
+//	out, err := shellx.NewCmd("git", "status").WithExecutor(fake).ExecStdout()
+//
+//	// 或者替换包级默认执行器, 作用于所有命令(包括便捷函数), 测试结束后自动恢复
+//	fake.Install()
+//
+// 注意事项:
+//   - 未匹配任何期望的命令会使测试失败 (t.Errorf), 并返回 ErrUnexpectedCommand
+//   - Install 修改的是全局状态, 不要在并行测试中使用
+//   - 标准输入在后台读取, 假进程在标准输入关闭后才退出; 信号由 Expectation.OnSignal 决定是否终止假进程
+package shellxtest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/shellx"
+)
+
+// ErrUnexpectedCommand 表示命令没有匹配任何期望
+var ErrUnexpectedCommand = errors.New("shellxtest: unexpected command")
+
+// errKilled 表示假进程被终止
+var errKilled = errors.New("signal: killed")
+
+// SignalHandler 处理发送给假进程的信号
+//
+// 参数:
+//   - sig: 信号
+//
+// 返回:
+//   - bool: 是否终止假进程
+type SignalHandler func(sig os.Signal) bool
+
+// Call 记录一次命令调用
+type Call struct {
+	Name    string      // 命令名
+	Args    []string    // 命令参数
+	CmdStr  string      // 完整命令字符串
+	Dir     string      // 工作目录
+	Env     []string    // 环境变量
+	Stdin   []byte      // 读取到的标准输入
+	Signals []os.Signal // 收到的信号 (不包括 Kill 和信号0)
+}
+
+// Expectation 描述一条命令期望及其脚本化的执行结果
+type Expectation struct {
+	// 匹配条件
+	name   string         // 命令名 (空表示不限制)
+	cmdStr string         // 完整命令字符串 (空表示不限制)
+	args   *regexp.Regexp // 参数正则 (nil表示不限制)
+
+	// 脚本化结果
+	stdout   string        // 标准输出
+	stderr   string        // 标准错误
+	exitCode int           // 退出码
+	delay    time.Duration // 延迟
+	err      error         // 启动错误
+	onSignal SignalHandler // 信号处理函数 (nil表示使用默认处理)
+
+	// 匹配次数
+	times int // 允许匹配的次数 (0表示不限)
+	calls int // 已匹配的次数
+}
+
+// FakeExecutor 内存中的假执行器
+type FakeExecutor struct {
+	t            testing.TB
+	mu           sync.Mutex
+	expectations []*Expectation
+	calls        []Call
+	nextPID      int
+}
+
+// NewFakeExecutor 创建假执行器
+//
+// 参数:
+//   - t: 测试对象, 遇到未预期的命令时用于报告失败
+//
+// 返回:
+//   - *FakeExecutor: 假执行器
+func NewFakeExecutor(t testing.TB) *FakeExecutor {
+	return &FakeExecutor{
+		t:       t,
+		nextPID: 10000,
+	}
+}
+
+// Install 将假执行器设置为 shellx 的包级默认执行器, 测试结束时自动恢复
+//
+// 返回:
+//   - *FakeExecutor: 假执行器 (支持链式调用)
+func (f *FakeExecutor) Install() *FakeExecutor {
+	prev := shellx.SetDefaultExecutor(f)
+	f.t.Cleanup(func() { shellx.SetDefaultExecutor(prev) })
+	return f
+}
+
+// Expect 添加按命令名匹配的期望
+//
+// 参数:
+//   - name: 命令名
+//
+// 返回:
+//   - *Expectation: 期望对象 (支持链式调用)
+func (f *FakeExecutor) Expect(name string) *Expectation {
+	e := &Expectation{name: name}
+	f.mu.Lock()
+	f.expectations = append(f.expectations, e)
+	f.mu.Unlock()
+	return e
+}
+
+// ExpectCmd 添加按完整命令字符串 (Command.CmdStr) 匹配的期望
+//
+// 参数:
+//   - cmdStr: 完整命令字符串
+//
+// 返回:
+//   - *Expectation: 期望对象 (支持链式调用)
+func (f *FakeExecutor) ExpectCmd(cmdStr string) *Expectation {
+	e := &Expectation{cmdStr: cmdStr}
+	f.mu.Lock()
+	f.expectations = append(f.expectations, e)
+	f.mu.Unlock()
+	return e
+}
+
+// Calls 获取所有调用记录 (包括未匹配的调用)
+//
+// 返回:
+//   - []Call: 调用记录的副本
+func (f *FakeExecutor) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	calls := make([]Call, len(f.calls))
+	copy(calls, f.calls)
+	for i := range calls {
+		calls[i].Signals = slices.Clone(calls[i].Signals)
+	}
+	return calls
+}
+
+// AssertExpectations 检查所有期望都被匹配过, 设置了 Times 的期望需要恰好匹配对应次数
+func (f *FakeExecutor) AssertExpectations() {
+	f.t.Helper()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, e := range f.expectations {
+		switch {
+		case e.times > 0 && e.calls != e.times:
+			f.t.Errorf("shellxtest: expectation %s matched %d times, want %d", e, e.calls, e.times)
+		case e.calls == 0:
+			f.t.Errorf("shellxtest: expectation %s was never matched", e)
+		}
+	}
+}
+
+// Start 实现 shellx.Executor 接口
+//
+// 参数:
+//   - c: 命令对象
+//
+// 返回:
+//   - shellx.Process: 假进程
+//   - error: 未匹配的命令或期望设置的启动错误
+func (f *FakeExecutor) Start(c *shellx.Command) (shellx.Process, error) {
+	call := Call{
+		Name:   c.Name(),
+		Args:   c.Args(),
+		CmdStr: c.CmdStr(),
+		Dir:    c.WorkDir(),
+		Env:    c.Env(),
+	}
+
+	f.mu.Lock()
+	index := len(f.calls)
+	f.calls = append(f.calls, call)
+	e := f.match(call)
+	f.nextPID++
+	pid := f.nextPID
+	f.mu.Unlock()
+
+	if e == nil {
+		f.t.Errorf("shellxtest: unexpected command: %s", call.CmdStr)
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedCommand, call.CmdStr)
+	}
+
+	if e.err != nil {
+		return nil, e.err
+	}
+
+	p := &fakeProcess{
+		f:        f,
+		index:    index,
+		pid:      pid,
+		onSignal: e.onSignal,
+		done:     make(chan struct{}),
+		kill:     make(chan struct{}),
+		stdin:    make(chan struct{}),
+	}
+
+	// 在后台读取标准输入, 标准输入不关闭时不会阻塞 Start, 假进程可以被 Kill 或超时终止
+	if r := c.Stdin(); r != nil {
+		go p.readStdin(r)
+	} else {
+		close(p.stdin)
+	}
+	go p.run(c, e)
+	return p, nil
+}
+
+// match 查找第一个匹配且仍有剩余次数的期望, 调用方需持有锁
+func (f *FakeExecutor) match(call Call) *Expectation {
+	for _, e := range f.expectations {
+		if e.times > 0 && e.calls >= e.times {
+			continue
+		}
+		if e.name != "" && e.name != call.Name {
+			continue
+		}
+		if e.cmdStr != "" && e.cmdStr != call.CmdStr {
+			continue
+		}
+		if e.args != nil && !e.args.MatchString(strings.Join(call.Args, " ")) {
+			continue
+		}
+		e.calls++
+		return e
+	}
+	return nil
+}
+
+// Args 限制参数必须匹配正则表达式 (参数以空格连接后匹配)
+//
+// 参数:
+//   - pattern: 正则表达式, 格式错误会 panic
+//
+// 返回:
+//   - *Expectation: 期望对象 (支持链式调用)
+func (e *Expectation) Args(pattern string) *Expectation {
+	e.args = regexp.MustCompile(pattern)
+	return e
+}
+
+// Stdout 设置写入标准输出的内容
+func (e *Expectation) Stdout(s string) *Expectation {
+	e.stdout = s
+	return e
+}
+
+// Stderr 设置写入标准错误的内容
+func (e *Expectation) Stderr(s string) *Expectation {
+	e.stderr = s
+	return e
+}
+
+// ExitCode 设置退出码, 非零时 Wait 返回 *shellx.ExitError
+func (e *Expectation) ExitCode(code int) *Expectation {
+	e.exitCode = code
+	return e
+}
+
+// Delay 设置命令结束前的延迟, 延迟期间会响应上下文取消和 Kill
+func (e *Expectation) Delay(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// Err 设置启动错误, 命令不会进入运行状态
+func (e *Expectation) Err(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// OnSignal 设置信号处理函数, 决定收到信号 (Kill 和信号0除外) 后假进程是否终止
+//
+// 参数:
+//   - fn: 信号处理函数, 返回 true 时假进程以 "signal: <信号名>" 错误结束
+//
+// 返回:
+//   - *Expectation: 期望对象 (支持链式调用)
+//
+// 注意:
+//   - 未设置时 SIGKILL、SIGTERM 和 SIGINT 终止假进程, 其他信号只被记录
+//   - 收到的信号记录在 Call.Signals 中
+func (e *Expectation) OnSignal(fn SignalHandler) *Expectation {
+	e.onSignal = fn
+	return e
+}
+
+// Times 设置期望允许匹配的次数, 超过次数后不再匹配
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+// String 返回期望的描述
+func (e *Expectation) String() string {
+	var parts []string
+	if e.name != "" {
+		parts = append(parts, "name="+e.name)
+	}
+	if e.cmdStr != "" {
+		parts = append(parts, fmt.Sprintf("cmd=%q", e.cmdStr))
+	}
+	if e.args != nil {
+		parts = append(parts, fmt.Sprintf("args=/%s/", e.args))
+	}
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+// fakeProcess 假进程
+type fakeProcess struct {
+	f        *FakeExecutor
+	index    int // 调用记录的下标
+	pid      int
+	onSignal SignalHandler
+	done     chan struct{}
+	kill     chan struct{}
+	killOnce sync.Once
+	killErr  error         // 终止假进程的原因, 关闭 kill 之前设置
+	stdin    chan struct{} // 标准输入读取结束时关闭
+	err      error
+}
+
+// readStdin 读取标准输入并写入调用记录, 便于对输入内容进行断言
+func (p *fakeProcess) readStdin(r io.Reader) {
+	defer close(p.stdin)
+
+	data, _ := io.ReadAll(r)
+	p.f.mu.Lock()
+	p.f.calls[p.index].Stdin = data
+	p.f.mu.Unlock()
+}
+
+// run 模拟进程执行
+//
+// 注意:
+//   - 与读取标准输入的真实命令一样, 假进程在标准输入读取结束后才退出
+func (p *fakeProcess) run(c *shellx.Command, e *Expectation) {
+	defer close(p.done)
+
+	var timeout <-chan time.Time
+	if e.delay > 0 {
+		timer := time.NewTimer(e.delay)
+		defer timer.Stop()
+		timeout = timer.C
+	} else {
+		ch := make(chan time.Time)
+		close(ch)
+		timeout = ch
+	}
+
+	for stdin := p.stdin; stdin != nil || timeout != nil; {
+		select {
+		case <-timeout:
+			timeout = nil
+		case <-stdin:
+			stdin = nil
+		case <-c.Context().Done():
+			p.err = c.Context().Err()
+			return
+		case <-p.kill:
+			p.err = p.killErr
+			return
+		}
+	}
+
+	writeTo(c.Stdout(), e.stdout)
+	writeTo(c.Stderr(), e.stderr)
+
+	if e.exitCode != 0 {
+		p.err = &shellx.ExitError{Code: e.exitCode}
+	}
+}
+
+// writeTo 将内容写入输出, 输出为nil时丢弃
+func writeTo(w io.Writer, s string) {
+	if w == nil || s == "" {
+		return
+	}
+	_, _ = io.Copy(w, bytes.NewReader([]byte(s)))
+}
+
+func (p *fakeProcess) Pid() int {
+	return p.pid
+}
+
+func (p *fakeProcess) Wait() error {
+	<-p.done
+	return p.err
+}
+
+func (p *fakeProcess) Signal(sig os.Signal) error {
+	select {
+	case <-p.done:
+		return os.ErrProcessDone
+	default:
+	}
+
+	// 信号0仅用于检查进程是否存在
+	if sig == syscall.Signal(0) {
+		return nil
+	}
+	if sig == os.Kill {
+		return p.Kill()
+	}
+
+	p.f.mu.Lock()
+	p.f.calls[p.index].Signals = append(p.f.calls[p.index].Signals, sig)
+	p.f.mu.Unlock()
+
+	handler := p.onSignal
+	if handler == nil {
+		handler = defaultSignalHandler
+	}
+	if handler(sig) {
+		p.terminate(fmt.Errorf("signal: %v", sig))
+	}
+	return nil
+}
+
+func (p *fakeProcess) Kill() error {
+	select {
+	case <-p.done:
+		return os.ErrProcessDone
+	default:
+	}
+
+	p.terminate(errKilled)
+	return nil
+}
+
+// terminate 终止假进程, 只有第一次调用生效
+func (p *fakeProcess) terminate(err error) {
+	p.killOnce.Do(func() {
+		p.killErr = err
+		close(p.kill)
+	})
+}
+
+// defaultSignalHandler 默认信号处理: 终止信号结束假进程, 其他信号忽略
+func defaultSignalHandler(sig os.Signal) bool {
+	return sig == syscall.SIGTERM || sig == os.Interrupt
+}
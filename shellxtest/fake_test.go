@@ -0,0 +1,245 @@
+package shellxtest
+
+import (
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"gitee.com/MM-Q/shellx"
+)
+
+// TestFakeExecutorStdout 测试按命令名和参数正则匹配并返回标准输出
+func TestFakeExecutorStdout(t *testing.T) {
+	fake := NewFakeExecutor(t)
+	fake.Expect("git").Args(`^status`).Stdout("clean\n")
+
+	out, err := shellx.NewCmd("git", "status", "--short").WithExecutor(fake).ExecStdout()
+	if err != nil {
+		t.Fatalf("期望执行成功, 实际错误: %v", err)
+	}
+	if string(out) != "clean\n" {
+		t.Errorf("期望输出 'clean\\n', 实际为 %q", out)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 1 || calls[0].Name != "git" || calls[0].Args[0] != "status" {
+		t.Errorf("调用记录不符合预期: %+v", calls)
+	}
+	fake.AssertExpectations()
+}
+
+// TestFakeExecutorExitCode 测试脚本化的退出码和标准错误
+func TestFakeExecutorExitCode(t *testing.T) {
+	fake := NewFakeExecutor(t)
+	fake.ExpectCmd("make build").ExitCode(2).Stderr("build failed\n")
+
+	out, err := shellx.NewCmdStr("make build").WithExecutor(fake).ExecOutput()
+	if err == nil {
+		t.Fatal("期望返回错误")
+	}
+	if !strings.Contains(err.Error(), "exited with code 2") {
+		t.Errorf("错误信息应包含退出码, 实际为: %v", err)
+	}
+	if string(out) != "build failed\n" {
+		t.Errorf("期望合并输出包含标准错误, 实际为 %q", out)
+	}
+
+	cmd := shellx.NewCmdStr("make build").WithExecutor(fake)
+	if err := cmd.ExecAsync(); err != nil {
+		t.Fatalf("异步执行失败: %v", err)
+	}
+	code, _ := cmd.WaitWithCode()
+	if code != 2 {
+		t.Errorf("期望退出码为 2, 实际为 %d", code)
+	}
+}
+
+// TestFakeExecutorStdin 测试记录标准输入
+func TestFakeExecutorStdin(t *testing.T) {
+	fake := NewFakeExecutor(t)
+	fake.Expect("cat")
+
+	err := shellx.NewCmd("cat").WithStdin(strings.NewReader("input")).WithExecutor(fake).Exec()
+	if err != nil {
+		t.Fatalf("期望执行成功, 实际错误: %v", err)
+	}
+	if got := string(fake.Calls()[0].Stdin); got != "input" {
+		t.Errorf("期望记录的标准输入为 'input', 实际为 %q", got)
+	}
+}
+
+// TestFakeExecutorStdinNotClosed 测试标准输入不关闭时 Start 不阻塞
+func TestFakeExecutorStdinNotClosed(t *testing.T) {
+	fake := NewFakeExecutor(t)
+	fake.Expect("cat")
+
+	r, w := io.Pipe()
+	defer func() { _ = w.Close() }()
+
+	cmd := shellx.NewCmd("cat").WithStdin(r).WithExecutor(fake).WithTimeout(50 * time.Millisecond)
+	done := make(chan error, 1)
+	go func() { done <- cmd.Exec() }()
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "timeout") {
+			t.Errorf("期望超时错误, 实际为: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("标准输入未关闭时命令不应阻塞")
+	}
+}
+
+// TestFakeExecutorSignal 测试信号的记录与处理
+func TestFakeExecutorSignal(t *testing.T) {
+	t.Run("默认处理", func(t *testing.T) {
+		fake := NewFakeExecutor(t)
+		fake.Expect("sleep").Delay(50 * time.Millisecond)
+
+		cmd := shellx.NewCmd("sleep").WithExecutor(fake)
+		if err := cmd.ExecAsync(); err != nil {
+			t.Fatalf("异步执行失败: %v", err)
+		}
+		if err := cmd.Signal(syscall.Signal(28)); err != nil {
+			t.Errorf("发送信号失败: %v", err)
+		}
+		if err := cmd.Wait(); err != nil {
+			t.Errorf("非终止信号不应结束命令, 实际错误: %v", err)
+		}
+		if sigs := fake.Calls()[0].Signals; len(sigs) != 1 || sigs[0] != syscall.Signal(28) {
+			t.Errorf("期望记录信号 28, 实际为 %v", sigs)
+		}
+	})
+
+	t.Run("OnSignal", func(t *testing.T) {
+		fake := NewFakeExecutor(t)
+		fake.Expect("server").Delay(time.Second).OnSignal(func(sig os.Signal) bool {
+			return sig == os.Interrupt
+		})
+
+		cmd := shellx.NewCmd("server").WithExecutor(fake)
+		if err := cmd.ExecAsync(); err != nil {
+			t.Fatalf("异步执行失败: %v", err)
+		}
+		_ = cmd.Signal(syscall.SIGTERM)
+		if !cmd.IsRunning() {
+			t.Error("处理函数忽略的信号不应结束命令")
+		}
+		_ = cmd.Signal(os.Interrupt)
+		if err := cmd.Wait(); err == nil || !strings.Contains(err.Error(), "interrupt") {
+			t.Errorf("期望被中断的错误, 实际为: %v", err)
+		}
+		if sigs := fake.Calls()[0].Signals; len(sigs) != 2 {
+			t.Errorf("期望记录2个信号, 实际为 %v", sigs)
+		}
+	})
+}
+
+// TestFakeExecutorErr 测试脚本化的启动错误
+func TestFakeExecutorErr(t *testing.T) {
+	fake := NewFakeExecutor(t)
+	fake.Expect("missing").Err(exec.ErrNotFound)
+
+	err := shellx.NewCmd("missing").WithExecutor(fake).Exec()
+	if err == nil || !strings.Contains(err.Error(), "command not found") {
+		t.Errorf("期望命令未找到错误, 实际为: %v", err)
+	}
+}
+
+// TestFakeExecutorDelay 测试延迟与超时、Kill的交互
+func TestFakeExecutorDelay(t *testing.T) {
+	t.Run("超时", func(t *testing.T) {
+		fake := NewFakeExecutor(t)
+		fake.Expect("sleep").Delay(time.Second)
+
+		err := shellx.NewCmd("sleep", "1").WithExecutor(fake).WithTimeout(20 * time.Millisecond).Exec()
+		if err == nil || !strings.Contains(err.Error(), "timeout") {
+			t.Errorf("期望超时错误, 实际为: %v", err)
+		}
+	})
+
+	t.Run("Kill", func(t *testing.T) {
+		fake := NewFakeExecutor(t)
+		fake.Expect("sleep").Delay(time.Second)
+
+		cmd := shellx.NewCmd("sleep", "1").WithExecutor(fake)
+		if err := cmd.ExecAsync(); err != nil {
+			t.Fatalf("异步执行失败: %v", err)
+		}
+		if !cmd.IsRunning() || cmd.GetPID() == 0 {
+			t.Error("延迟期间命令应处于运行状态")
+		}
+		if err := cmd.Kill(); err != nil {
+			t.Errorf("Kill失败: %v", err)
+		}
+		if err := cmd.Wait(); err == nil {
+			t.Error("被Kill的命令应返回错误")
+		}
+		if cmd.IsRunning() {
+			t.Error("结束后的命令不应处于运行状态")
+		}
+	})
+}
+
+// TestFakeExecutorTimes 测试匹配次数限制
+func TestFakeExecutorTimes(t *testing.T) {
+	fake := NewFakeExecutor(t)
+	fake.Expect("echo").Times(1).Stdout("first")
+	fake.Expect("echo").Stdout("rest")
+
+	for _, want := range []string{"first", "rest", "rest"} {
+		out, err := shellx.NewCmd("echo").WithExecutor(fake).ExecStdout()
+		if err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		if string(out) != want {
+			t.Errorf("期望输出 %q, 实际为 %q", want, out)
+		}
+	}
+}
+
+// TestFakeExecutorUnexpected 测试未预期的命令会使测试失败
+func TestFakeExecutorUnexpected(t *testing.T) {
+	rec := &recordingTB{TB: t}
+	fake := NewFakeExecutor(rec)
+
+	_, err := fake.Start(shellx.NewCmd("rm", "-rf", "/"))
+	if !errors.Is(err, ErrUnexpectedCommand) {
+		t.Errorf("期望 ErrUnexpectedCommand, 实际为: %v", err)
+	}
+	if !rec.failed {
+		t.Error("未预期的命令应使测试失败")
+	}
+}
+
+// TestFakeExecutorInstall 测试替换包级默认执行器
+func TestFakeExecutorInstall(t *testing.T) {
+	t.Run("安装", func(t *testing.T) {
+		fake := NewFakeExecutor(t).Install()
+		fake.Expect("hostname").Stdout("fake-host")
+
+		out, err := shellx.ExecOut("hostname")
+		if err != nil || string(out) != "fake-host" {
+			t.Errorf("便捷函数应使用默认执行器, 输出: %q, 错误: %v", out, err)
+		}
+	})
+
+	if _, ok := shellx.DefaultExecutor().(shellx.OSExecutor); !ok {
+		t.Error("测试结束后应恢复默认执行器")
+	}
+}
+
+// recordingTB 记录失败状态而不真正让测试失败
+type recordingTB struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.failed = true
+}
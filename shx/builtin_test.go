@@ -0,0 +1,211 @@
+package shx
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// upper 将标准输入转换为大写的测试命令
+func upper(ctx HandlerContext, args []string) error {
+	data, err := io.ReadAll(ctx.Stdin)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(ctx.Stdout, strings.ToUpper(string(data)))
+	return err
+}
+
+func TestWithBuiltin(t *testing.T) {
+	var got []string
+	output, err := New(`greet "hello world" x`).
+		WithBuiltin("greet", func(ctx HandlerContext, args []string) error {
+			got = args
+			_, err := fmt.Fprintf(ctx.Stdout, "%s: %d args\n", ctx.Name, len(args))
+			return err
+		}).
+		ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+
+	if strings.Join(got, "|") != "hello world|x" {
+		t.Fatalf("unexpected args: %q", got)
+	}
+	if string(output) != "greet: 2 args\n" {
+		t.Fatalf("unexpected output: %q", output)
+	}
+}
+
+func TestBuiltinPipeline(t *testing.T) {
+	output, err := New(`echo abc | upper | upper`).WithBuiltin("upper", upper).ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if string(output) != "ABC\n" {
+		t.Fatalf("unexpected output: %q", output)
+	}
+}
+
+func TestBuiltinRedirect(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "in.txt"), []byte("data\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := New(`upper < in.txt > out.txt`).WithDir(dir).WithBuiltin("upper", upper).Exec()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "DATA\n" {
+		t.Fatalf("unexpected file content: %q", data)
+	}
+}
+
+func TestBuiltinExitStatus(t *testing.T) {
+	fail := func(ctx HandlerContext, args []string) error {
+		return ExitStatus{Code: 3}
+	}
+
+	// 退出码可以在脚本中获取
+	output, err := New(`fail; echo "code=$?"`).WithBuiltin("fail", fail).ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if string(output) != "code=3\n" {
+		t.Fatalf("unexpected output: %q", output)
+	}
+
+	// 作为最后一条命令时返回退出码
+	err = New(`fail`).WithBuiltin("fail", fail).Exec()
+	if code, ok := IsExitStatus(err); !ok || code != 3 {
+		t.Fatalf("expected exit status 3, got %v", err)
+	}
+
+	// 用于条件判断
+	output, err = New(`if fail; then echo yes; else echo no; fi`).WithBuiltin("fail", fail).ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if string(output) != "no\n" {
+		t.Fatalf("unexpected output: %q", output)
+	}
+}
+
+func TestBuiltinError(t *testing.T) {
+	output, err := New(`broken; echo "code=$?"`).
+		WithBuiltin("broken", func(ctx HandlerContext, args []string) error {
+			return errors.New("something went wrong")
+		}).
+		ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+
+	want := "broken: something went wrong\ncode=1\n"
+	if string(output) != want {
+		t.Fatalf("expected %q, got %q", want, output)
+	}
+}
+
+func TestBuiltinEnvAndDir(t *testing.T) {
+	dir := t.TempDir()
+
+	if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var gotVersion, gotLocal, gotDir string
+	err := New(`LOCAL=inline; cd sub && notify`).
+		WithDir(dir).
+		WithEnv("VERSION", "1.2.0").
+		WithBuiltin("notify", func(ctx HandlerContext, args []string) error {
+			gotVersion = ctx.Getenv("VERSION")
+			gotLocal = ctx.Getenv("LOCAL")
+			gotDir = ctx.Dir
+			return nil
+		}).
+		Exec()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+
+	if gotVersion != "1.2.0" {
+		t.Errorf("expected VERSION 1.2.0, got %q", gotVersion)
+	}
+	if gotLocal != "inline" {
+		t.Errorf("expected LOCAL inline, got %q", gotLocal)
+	}
+	if gotDir != filepath.Join(dir, "sub") {
+		t.Errorf("expected dir %q, got %q", filepath.Join(dir, "sub"), gotDir)
+	}
+}
+
+func TestBuiltinPrecedence(t *testing.T) {
+	called := false
+	fn := func(ctx HandlerContext, args []string) error {
+		called = true
+		return nil
+	}
+
+	// 脚本中定义的函数优先
+	output, err := New(`ls() { echo func; }; ls`).WithBuiltin("ls", fn).ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if string(output) != "func\n" || called {
+		t.Fatalf("expected shell function to win, output %q, called %v", output, called)
+	}
+
+	// 优先于 PATH 中的同名程序
+	if err := New(`ls`).WithBuiltin("ls", fn).Exec(); err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if !called {
+		t.Fatal("expected builtin to override PATH lookup")
+	}
+}
+
+func TestBuiltinContext(t *testing.T) {
+	err := New(`wait-done`).
+		WithTimeout(50*time.Millisecond).
+		WithBuiltin("wait-done", func(ctx HandlerContext, args []string) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}).
+		Exec()
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
+
+func TestWithBuiltinPanics(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+	}{
+		{"empty name", func() { New("x").WithBuiltin("", upper) }},
+		{"nil func", func() { New("x").WithBuiltin("x", nil) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil {
+					t.Fatal("expected panic")
+				}
+			}()
+			tt.fn()
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
 )
 
 // 预定义错误
@@ -22,8 +23,37 @@ var (
 
 	// ErrNilWriter 表示 writer 为 nil
 	ErrNilWriter = errors.New("writer cannot be nil")
+
+	// ErrFSPolicy 表示文件访问违反了文件系统策略
+	ErrFSPolicy = errors.New("filesystem policy violation")
 )
 
+// FSPolicyError 表示文件访问违反了 WithFSRoot、WithReadOnlyFS 设置的策略
+type FSPolicyError struct {
+	Op     string     // 操作: read、write、stat、readdir
+	Path   string     // 访问的绝对路径
+	Reason string     // 拒绝原因
+	Name   string     // 脚本名称
+	Pos    syntax.Pos // 触发访问的脚本位置, 无法确定时无效
+}
+
+// Error 实现 error 接口
+func (e *FSPolicyError) Error() string {
+	msg := fmt.Sprintf("%s %s: %s", e.Op, e.Path, e.Reason)
+	if e.Pos.IsValid() {
+		msg = e.Pos.String() + ": " + msg
+		if e.Name != "" {
+			msg = e.Name + ":" + msg
+		}
+	}
+	return msg
+}
+
+// Is 使 errors.Is(err, ErrFSPolicy) 成立
+func (e *FSPolicyError) Is(target error) bool {
+	return target == ErrFSPolicy
+}
+
 // handleError 处理执行错误
 //
 // 参数：
@@ -3,6 +3,7 @@ package shx
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -165,14 +166,36 @@ func (s *Shx) execWithContext(ctx context.Context) error {
 		return fmt.Errorf("parse error: %w", err)
 	}
 
+	// $0 取自文件名
+	if s.arg0 != "" {
+		file.Name = s.arg0
+	}
+
+	// 设置了文件系统策略时创建沙箱, 违规时通过取消上下文停止执行
+	var sb *fsSandbox
+	if s.fsEnabled() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithCancel(ctx)
+		defer cancel()
+
+		if sb, err = s.newFSSandbox(file, cancel); err != nil {
+			return err
+		}
+	}
+
 	// 创建执行器
-	runner, err := s.buildRunner()
+	runner, err := s.buildRunner(sb)
 	if err != nil {
 		return err
 	}
 
-	// 执行命令
+	// 执行命令, 违反文件系统策略时优先返回 *FSPolicyError
 	err = runner.Run(ctx, file)
+	if sb != nil {
+		if perr := sb.violation(); perr != nil {
+			return perr
+		}
+	}
 	return handleError(err, s.displayName(), s.timeout)
 }
 
@@ -186,15 +209,76 @@ func (s *Shx) displayName() string {
 
 // buildRunner 构建执行器
 //
+// 参数:
+//   - sb: 文件系统沙箱, 未设置文件系统策略时为 nil
+//
 // 返回:
 //   - *interp.Runner: 执行器
 //   - error: 构建错误
-func (s *Shx) buildRunner() (*interp.Runner, error) {
+func (s *Shx) buildRunner(sb *fsSandbox) (*interp.Runner, error) {
+	dir := s.dir
+	if sb != nil {
+		dir = sb.startDir(dir)
+	}
+
 	opts := []interp.RunnerOption{
 		interp.Env(s.env),
-		interp.Dir(s.dir),
+		interp.Dir(dir),
 		interp.StdIO(s.stdin, s.stdout, s.stderr),
 	}
 
+	if sb != nil {
+		opts = append(opts, sb.options()...)
+	}
+
+	if len(s.builtins) > 0 {
+		opts = append(opts, interp.ExecHandlers(s.builtinHandler))
+	}
+
+	// "--" 之后的参数全部作为位置参数, 避免以 - 开头的参数被解析为选项
+	if len(s.args) > 0 {
+		opts = append(opts, interp.Params(append([]string{"--"}, s.args...)...))
+	}
+
 	return interp.New(opts...)
 }
+
+// builtinHandler 执行注册的 Go 函数命令, 其他命令交给下一个处理器
+//
+// 参数:
+//   - next: 下一个处理器
+//
+// 返回:
+//   - interp.ExecHandlerFunc: 处理器
+func (s *Shx) builtinHandler(next interp.ExecHandlerFunc) interp.ExecHandlerFunc {
+	return func(ctx context.Context, args []string) error {
+		fn, ok := s.builtins[args[0]]
+		if !ok {
+			return next(ctx, args)
+		}
+
+		hc := interp.HandlerCtx(ctx)
+		err := fn(HandlerContext{
+			Context: ctx,
+			Name:    args[0],
+			Stdin:   hc.Stdin,
+			Stdout:  hc.Stdout,
+			Stderr:  hc.Stderr,
+			Env:     hc.Env,
+			Dir:     hc.Dir,
+		}, args[1:])
+		if err == nil {
+			return nil
+		}
+
+		// 退出码交给解释器处理, 取消和超时原样返回以终止执行, 其他错误按命令失败处理
+		if code, ok := IsExitStatus(err); ok {
+			return interp.ExitStatus(code)
+		}
+		if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+			return err
+		}
+		_, _ = fmt.Fprintf(hc.Stderr, "%s: %v\n", args[0], err)
+		return interp.ExitStatus(1)
+	}
+}
@@ -143,6 +143,22 @@ func RunScript(filePath string) error {
 	return NewScript(filePath).Exec()
 }
 
+// RunScriptArgs 使用位置参数执行 bash 脚本文件
+//
+// 参数：
+//   - filePath: 脚本文件路径
+//   - args: 位置参数 ($1..$n)
+//
+// 返回：
+//   - error: 执行错误
+//
+// 示例：
+//
+//	err := shx.RunScriptArgs("deploy.sh", "prod", "--force")
+func RunScriptArgs(filePath string, args ...string) error {
+	return NewScript(filePath).WithArgs(args...).Exec()
+}
+
 // RunScriptToTerminal 执行 bash 脚本文件并输出到终端
 //
 // 参数：
@@ -177,6 +193,23 @@ func OutScript(filePath string) ([]byte, error) {
 	return NewScript(filePath).ExecOutput()
 }
 
+// OutScriptArgs 使用位置参数执行 bash 脚本文件并获取输出
+//
+// 参数：
+//   - filePath: 脚本文件路径
+//   - args: 位置参数 ($1..$n)
+//
+// 返回：
+//   - []byte: 命令输出
+//   - error: 执行错误
+//
+// 示例：
+//
+//	output, err := shx.OutScriptArgs("build.sh", "linux", "amd64")
+func OutScriptArgs(filePath string, args ...string) ([]byte, error) {
+	return NewScript(filePath).WithArgs(args...).ExecOutput()
+}
+
 // RunScriptWith 超时执行 bash 脚本文件
 //
 // 参数：
@@ -173,6 +173,172 @@ func (s *Shx) WithEnvs(envs []string) *Shx {
 	return s
 }
 
+// WithArgs 设置位置参数
+//
+// 参数:
+//   - args: 位置参数, 在命令或脚本中通过 $1..$n、$@、$# 获取
+//
+// 返回:
+//   - *Shx: 命令对象 (支持链式调用)
+//
+// 注意:
+//   - 如果命令已经执行过, 会 panic
+//   - 多次调用时覆盖之前设置的位置参数
+//   - 参数原样传入, 不会进行分词或展开
+//
+// 示例:
+//
+//	shx.NewScript("deploy.sh").WithArgs("prod", "--force").Exec()
+func (s *Shx) WithArgs(args ...string) *Shx {
+	if s.executed.Load() {
+		panic("shx has already been executed")
+	}
+
+	s.args = append([]string(nil), args...)
+	return s
+}
+
+// WithArg0 设置 $0
+//
+// 参数:
+//   - name: $0 的值
+//
+// 返回:
+//   - *Shx: 命令对象 (支持链式调用)
+//
+// 注意:
+//   - 如果命令已经执行过, 会 panic
+//   - 默认值: 脚本文件为文件路径, 命令字符串为 "gosh"
+//   - 运行时错误信息中的位置也使用该名称
+func (s *Shx) WithArg0(name string) *Shx {
+	if s.executed.Load() {
+		panic("shx has already been executed")
+	}
+
+	s.arg0 = name
+	return s
+}
+
+// WithBuiltin 将 Go 函数注册为 shell 命令
+//
+// 参数:
+//   - name: 命令名
+//   - fn: 命令的实现
+//
+// 返回:
+//   - *Shx: 命令对象 (支持链式调用)
+//
+// 注意:
+//   - 如果命令已经执行过, 会 panic
+//   - 如果 name 为空或 fn 为 nil, 会 panic
+//   - 注册的命令可以像外部命令一样用于管道和重定向, 并优先于 PATH 中的同名程序
+//   - shell 内置命令 (如 echo、cd) 和脚本中定义的函数优先于注册的命令
+//   - 通过管道连接时函数可能在不同的 goroutine 中并发执行
+//
+// 示例:
+//
+//	err := shx.New(`deploy-notify "$VERSION" | tee notify.log`).
+//		WithEnv("VERSION", "1.2.0").
+//		WithBuiltin("deploy-notify", func(ctx shx.HandlerContext, args []string) error {
+//			if len(args) == 0 {
+//				return shx.ExitStatus{Code: 2}
+//			}
+//			_, err := fmt.Fprintf(ctx.Stdout, "deployed %s\n", args[0])
+//			return err
+//		}).
+//		Exec()
+func (s *Shx) WithBuiltin(name string, fn BuiltinFunc) *Shx {
+	if s.executed.Load() {
+		panic("shx has already been executed")
+	}
+	if name == "" {
+		panic("builtin name must not be empty")
+	}
+	if fn == nil {
+		panic("builtin func must not be nil")
+	}
+
+	if s.builtins == nil {
+		s.builtins = make(map[string]BuiltinFunc)
+	}
+	s.builtins[name] = fn
+	return s
+}
+
+// WithFSRoot 将文件访问限制在指定目录之内
+//
+// 参数:
+//   - dir: 根目录, 相对路径相对于工作目录
+//
+// 返回:
+//   - *Shx: 命令对象 (支持链式调用)
+//
+// 注意:
+//   - 如果命令已经执行过, 会 panic
+//   - 限制重定向、cd、source、test 等内置命令以及通配符展开, 符号链接按目标路径检查
+//   - 访问根目录之外的路径时停止执行并返回 *FSPolicyError, 根目录不存在时返回错误
+//   - 工作目录在根目录之外时从根目录开始执行
+//   - 不限制外部程序和 WithBuiltin 注册的 Go 函数
+//
+// 示例:
+//
+//	err := shx.New(`cd build && cat version.txt > ../out.txt`).
+//		WithFSRoot("/srv/project").
+//		WithFSAllow("/etc/ssl").
+//		Exec()
+//	var perr *shx.FSPolicyError
+//	if errors.As(err, &perr) {
+//		log.Printf("script touched %s at %s", perr.Path, perr.Pos)
+//	}
+func (s *Shx) WithFSRoot(dir string) *Shx {
+	if s.executed.Load() {
+		panic("shx has already been executed")
+	}
+
+	s.fsRoot = dir
+	return s
+}
+
+// WithReadOnlyFS 禁止写入文件
+//
+// 返回:
+//   - *Shx: 命令对象 (支持链式调用)
+//
+// 注意:
+//   - 如果命令已经执行过, 会 panic
+//   - 拒绝任何写入、创建和截断 (如 > file、>> file), 写入 /dev/null 除外
+//   - 违反时停止执行并返回 *FSPolicyError
+//   - 不限制外部程序和 WithBuiltin 注册的 Go 函数
+func (s *Shx) WithReadOnlyFS() *Shx {
+	if s.executed.Load() {
+		panic("shx has already been executed")
+	}
+
+	s.fsReadOnly = true
+	return s
+}
+
+// WithFSAllow 允许访问根目录之外的路径
+//
+// 参数:
+//   - paths: 允许访问的文件或目录, 目录包括其下的所有路径; 相对路径相对于工作目录
+//
+// 返回:
+//   - *Shx: 命令对象 (支持链式调用)
+//
+// 注意:
+//   - 如果命令已经执行过, 会 panic
+//   - 只在设置了 WithFSRoot 时生效, 设置了 WithReadOnlyFS 时同样只读
+//   - 多次调用时追加
+func (s *Shx) WithFSAllow(paths ...string) *Shx {
+	if s.executed.Load() {
+		panic("shx has already been executed")
+	}
+
+	s.fsAllow = append(s.fsAllow, paths...)
+	return s
+}
+
 // WithStdin 设置标准输入
 //
 // 参数:
@@ -0,0 +1,340 @@
+package shx
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"mvdan.cc/sh/v3/expand"
+	"mvdan.cc/sh/v3/interp"
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// 文件系统策略的拒绝原因
+const (
+	reasonOutsideRoot = "outside filesystem root"
+	reasonReadOnly    = "read-only filesystem"
+)
+
+// writeFlags 表示写入、创建或截断的打开标志
+const writeFlags = os.O_WRONLY | os.O_RDWR | os.O_CREATE | os.O_TRUNC | os.O_APPEND
+
+// maxSymlinks 解析一个路径时最多跟随的符号链接数量, 与 Linux 内核的限制一致
+const maxSymlinks = 40
+
+// fsSandbox 文件系统沙箱
+//
+// 注意:
+//   - 管道中的命令会并发访问沙箱, 可变状态由 mu 保护
+//   - 发生违规时取消执行上下文, 解释器在下一条命令之前停止
+type fsSandbox struct {
+	root     string                     // 根目录的真实路径 (空=不限制)
+	allow    []string                   // 根目录之外允许访问的真实路径
+	readOnly bool                       // 是否禁止写入
+	name     string                     // 脚本名称, 用于错误信息
+	redirs   []*syntax.Redirect         // 脚本中的重定向, 用于定位违规位置
+	cancel   context.CancelFunc         // 取消执行上下文
+	open     interp.OpenHandlerFunc     // 检查通过后使用的默认处理器
+	stat     interp.StatHandlerFunc     // 检查通过后使用的默认处理器
+	readDir  interp.ReadDirHandlerFunc2 // 检查通过后使用的默认处理器
+
+	mu     sync.Mutex
+	cmdPos syntax.Pos     // 最近执行的命令的位置
+	err    *FSPolicyError // 第一次违规
+}
+
+// fsEnabled 判断是否设置了文件系统策略
+func (s *Shx) fsEnabled() bool {
+	return s.fsRoot != "" || s.fsReadOnly
+}
+
+// newFSSandbox 创建文件系统沙箱
+//
+// 参数:
+//   - file: 待执行的脚本, 用于定位违规位置
+//   - cancel: 取消执行上下文的函数
+//
+// 返回:
+//   - *fsSandbox: 沙箱
+//   - error: 根目录不存在或不是目录时返回错误
+func (s *Shx) newFSSandbox(file *syntax.File, cancel context.CancelFunc) (*fsSandbox, error) {
+	sb := &fsSandbox{
+		readOnly: s.fsReadOnly,
+		name:     file.Name,
+		cancel:   cancel,
+		open:     interp.DefaultOpenHandler(),
+		stat:     interp.DefaultStatHandler(),
+		readDir:  interp.DefaultReadDirHandler2(),
+	}
+
+	if s.fsRoot != "" {
+		root, err := filepath.EvalSymlinks(s.absPath(s.fsRoot))
+		if err != nil {
+			return nil, fmt.Errorf("invalid filesystem root: %w", err)
+		}
+		info, err := os.Stat(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filesystem root: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("invalid filesystem root: %s is not a directory", s.fsRoot)
+		}
+		sb.root = root
+
+		for _, p := range s.fsAllow {
+			sb.allow = append(sb.allow, realPath(s.absPath(p)))
+		}
+	}
+
+	syntax.Walk(file, func(node syntax.Node) bool {
+		if rd, ok := node.(*syntax.Redirect); ok && rd.Word != nil {
+			sb.redirs = append(sb.redirs, rd)
+		}
+		return true
+	})
+	return sb, nil
+}
+
+// absPath 将相对路径解析为相对于工作目录的绝对路径
+func (s *Shx) absPath(path string) string {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.dir, path)
+	}
+	return filepath.Clean(path)
+}
+
+// startDir 返回解释器的初始工作目录, 工作目录在根目录之外时使用根目录
+func (sb *fsSandbox) startDir(dir string) string {
+	if sb.root == "" || within(sb.root, realPath(dir)) {
+		return dir
+	}
+	return sb.root
+}
+
+// options 返回实现沙箱的解释器选项
+func (sb *fsSandbox) options() []interp.RunnerOption {
+	return []interp.RunnerOption{
+		interp.CallHandler(sb.callHandler),
+		interp.OpenHandler(sb.openHandler),
+		interp.StatHandler(sb.statHandler),
+		interp.ReadDirHandler2(sb.readDirHandler),
+	}
+}
+
+// callHandler 记录命令的位置, cd、source 等内置命令的文件访问发生在此之后
+func (sb *fsSandbox) callHandler(ctx context.Context, args []string) ([]string, error) {
+	if pos := interp.HandlerCtx(ctx).Pos; pos.IsValid() {
+		sb.mu.Lock()
+		sb.cmdPos = pos
+		sb.mu.Unlock()
+	}
+	return args, nil
+}
+
+// openHandler 检查重定向和 source 等内置命令打开的文件
+//
+// 注意:
+//   - 打开检查过的真实路径, 而不是原始路径, 保证实际访问的文件与检查的文件一致
+func (sb *fsSandbox) openHandler(ctx context.Context, path string, flag int, perm os.FileMode) (io.ReadWriteCloser, error) {
+	hc := interp.HandlerCtx(ctx)
+
+	op := "read"
+	if flag&writeFlags != 0 {
+		op = "write"
+	}
+	real, err := sb.check(&hc, op, joinDir(hc.Dir, path))
+	if err != nil {
+		return nil, err
+	}
+	return sb.open(ctx, real, flag, perm)
+}
+
+// statHandler 检查 cd、test 等获取文件信息的操作
+func (sb *fsSandbox) statHandler(ctx context.Context, name string, followSymlinks bool) (fs.FileInfo, error) {
+	// 解释器不为 stat 提供 HandlerContext, 传入的路径已经是绝对路径
+	// 只读取文件信息, 使用原始路径以保留不跟随最后一级符号链接的语义
+	if _, err := sb.check(nil, "stat", name); err != nil {
+		return nil, err
+	}
+	return sb.stat(ctx, name, followSymlinks)
+}
+
+// readDirHandler 检查通配符展开时读取的目录
+func (sb *fsSandbox) readDirHandler(ctx context.Context, path string) ([]fs.DirEntry, error) {
+	hc := interp.HandlerCtx(ctx)
+	real, err := sb.check(&hc, "readdir", joinDir(hc.Dir, path))
+	if err != nil {
+		return nil, err
+	}
+	return sb.readDir(ctx, real)
+}
+
+// check 检查文件访问是否符合策略
+//
+// 参数:
+//   - hc: 处理器上下文, 没有时为 nil
+//   - op: 操作
+//   - path: 绝对路径, 不需要预先清理
+//
+// 返回:
+//   - string: 解析符号链接后的真实路径, 访问文件时应使用该路径
+//   - error: 违反策略时返回 *FSPolicyError, 并取消执行
+func (sb *fsSandbox) check(hc *interp.HandlerContext, op, path string) (string, error) {
+	// 写入 /dev/null 不会产生任何效果, 始终允许
+	if path == "/dev/null" || path == os.DevNull {
+		return path, nil
+	}
+
+	real := realPath(path)
+	var reason string
+	switch {
+	case !sb.allowed(real):
+		reason = reasonOutsideRoot
+	case sb.readOnly && op == "write":
+		reason = reasonReadOnly
+	default:
+		return real, nil
+	}
+
+	err := &FSPolicyError{Op: op, Path: path, Reason: reason, Name: sb.name, Pos: sb.position(hc, path)}
+
+	sb.mu.Lock()
+	if sb.err == nil {
+		sb.err = err
+	}
+	sb.mu.Unlock()
+
+	sb.cancel()
+	return "", err
+}
+
+// allowed 判断真实路径是否位于根目录或允许的路径之内
+func (sb *fsSandbox) allowed(real string) bool {
+	if sb.root == "" || within(sb.root, real) {
+		return true
+	}
+	for _, p := range sb.allow {
+		if within(p, real) {
+			return true
+		}
+	}
+	return false
+}
+
+// position 返回触发访问的脚本位置
+//
+// 注意:
+//   - 解释器打开重定向文件时不提供位置, 通过展开脚本中的重定向目标并与路径比较来定位
+//   - 无法定位时使用最近执行的命令的位置
+func (sb *fsSandbox) position(hc *interp.HandlerContext, path string) syntax.Pos {
+	if hc != nil {
+		if hc.Pos.IsValid() {
+			return hc.Pos
+		}
+
+		cfg := &expand.Config{Env: hc.Env}
+		for _, rd := range sb.redirs {
+			target, err := expand.Literal(cfg, rd.Word)
+			if err != nil || target == "" {
+				continue
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(hc.Dir, target)
+			}
+			if filepath.Clean(target) == filepath.Clean(path) {
+				return rd.Pos()
+			}
+		}
+	}
+
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.cmdPos
+}
+
+// violation 返回第一次违规
+func (sb *fsSandbox) violation() error {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+
+	if sb.err == nil {
+		return nil
+	}
+	return sb.err
+}
+
+// joinDir 将相对路径拼接到目录之后, 不清理路径中的 ".."
+func joinDir(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return dir + string(filepath.Separator) + path
+}
+
+// realPath 按内核的方式逐级解析路径中的符号链接, 不存在的部分按字面处理
+//
+// 注意:
+//   - 不预先清理路径, 符号链接之后的 ".." 作用于链接目标的上级目录, 而不是链接所在的目录
+//   - 符号链接过多 (如存在循环) 时剩余部分按字面处理
+func realPath(path string) string {
+	if !filepath.IsAbs(path) {
+		if wd, err := os.Getwd(); err == nil {
+			path = joinDir(wd, path)
+		}
+	}
+
+	vol := filepath.VolumeName(path)
+	cur, rest := vol+string(filepath.Separator), path[len(vol):]
+	links := 0
+	for rest != "" {
+		name := rest
+		if i := strings.IndexFunc(rest, func(r rune) bool { return os.IsPathSeparator(uint8(r)) }); i >= 0 {
+			name, rest = rest[:i], rest[i+1:]
+		} else {
+			rest = ""
+		}
+
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			cur = filepath.Dir(cur)
+			continue
+		}
+
+		next := filepath.Join(cur, name)
+		info, err := os.Lstat(next)
+		if err != nil {
+			return filepath.Join(next, rest)
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			cur = next
+			continue
+		}
+
+		target, err := os.Readlink(next)
+		if links++; err != nil || links > maxSymlinks {
+			return filepath.Join(next, rest)
+		}
+		if filepath.IsAbs(target) {
+			vol := filepath.VolumeName(target)
+			cur, target = vol+string(filepath.Separator), target[len(vol):]
+		}
+		rest = target + string(filepath.Separator) + rest
+	}
+	return cur
+}
+
+// within 判断 path 是否为 base 或位于 base 之下
+func within(base, path string) bool {
+	rel, err := filepath.Rel(base, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
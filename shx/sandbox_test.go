@@ -0,0 +1,285 @@
+package shx
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// newSandboxDir 创建沙箱测试使用的目录结构
+//
+// 返回:
+//   - string: 根目录 (包含 in.txt 和 sub 目录)
+//   - string: 根目录之外的目录 (包含 secret.txt)
+func newSandboxDir(t *testing.T) (string, string) {
+	t.Helper()
+
+	base := t.TempDir()
+	root := filepath.Join(base, "root")
+	outside := filepath.Join(base, "outside")
+	for _, dir := range []string{filepath.Join(root, "sub"), outside} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "in.txt"), []byte("inside\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// 返回真实路径, 避免临时目录本身是符号链接 (如 macOS 的 /var)
+	root, _ = filepath.EvalSymlinks(root)
+	outside, _ = filepath.EvalSymlinks(outside)
+	return root, outside
+}
+
+// requirePolicyError 检查错误是否为指定的文件系统策略错误
+func requirePolicyError(t *testing.T, err error, op, reason string) *FSPolicyError {
+	t.Helper()
+
+	var perr *FSPolicyError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected *FSPolicyError, got %T: %v", err, err)
+	}
+	if !errors.Is(err, ErrFSPolicy) {
+		t.Fatal("expected errors.Is(err, ErrFSPolicy)")
+	}
+	if perr.Op != op || perr.Reason != reason {
+		t.Fatalf("expected %s/%s, got %s/%s", op, reason, perr.Op, perr.Reason)
+	}
+	return perr
+}
+
+func TestFSRootInside(t *testing.T) {
+	root, _ := newSandboxDir(t)
+
+	output, err := New(`cat < in.txt; cd sub && echo ok > out.txt && cat < out.txt; echo *.txt`).
+		WithFSRoot(root).
+		WithDir(root).
+		ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if string(output) != "inside\nok\nout.txt\n" {
+		t.Fatalf("unexpected output: %q", output)
+	}
+}
+
+func TestFSRootRedirect(t *testing.T) {
+	root, outside := newSandboxDir(t)
+	secret := filepath.Join(outside, "secret.txt")
+
+	output, err := New("echo start\ncat < " + secret + "\necho unreachable").
+		WithFSRoot(root).
+		WithDir(root).
+		ExecOutput()
+	perr := requirePolicyError(t, err, "read", reasonOutsideRoot)
+
+	if perr.Path != secret {
+		t.Errorf("expected path %q, got %q", secret, perr.Path)
+	}
+	if perr.Pos.Line() != 2 || perr.Pos.Col() != 5 {
+		t.Errorf("expected position 2:5, got %s", perr.Pos)
+	}
+	if strings.Contains(string(output), "unreachable") || strings.Contains(string(output), "secret") {
+		t.Errorf("execution should stop at violation: %q", output)
+	}
+	if !strings.Contains(err.Error(), "2:5: read "+secret) {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+func TestFSRootDynamicRedirect(t *testing.T) {
+	root, outside := newSandboxDir(t)
+
+	err := New("OUT=" + outside + "/x.txt\necho hi > \"$OUT\"").
+		WithFSRoot(root).
+		WithDir(root).
+		Exec()
+	perr := requirePolicyError(t, err, "write", reasonOutsideRoot)
+
+	if perr.Pos.Line() != 2 || perr.Pos.Col() != 9 {
+		t.Errorf("expected position 2:9, got %s", perr.Pos)
+	}
+	if _, statErr := os.Stat(filepath.Join(outside, "x.txt")); !os.IsNotExist(statErr) {
+		t.Error("file outside root should not be created")
+	}
+}
+
+func TestFSRootCd(t *testing.T) {
+	root, _ := newSandboxDir(t)
+
+	output, err := New("cd sub\ncd ../..\necho unreachable").
+		WithFSRoot(root).
+		WithDir(root).
+		ExecOutput()
+	perr := requirePolicyError(t, err, "stat", reasonOutsideRoot)
+
+	if perr.Path != filepath.Dir(root) {
+		t.Errorf("expected path %q, got %q", filepath.Dir(root), perr.Path)
+	}
+	if perr.Pos.Line() != 2 {
+		t.Errorf("expected line 2, got %s", perr.Pos)
+	}
+	if strings.Contains(string(output), "unreachable") {
+		t.Errorf("execution should stop at violation: %q", output)
+	}
+}
+
+func TestFSRootSource(t *testing.T) {
+	root, outside := newSandboxDir(t)
+	lib := filepath.Join(outside, "lib.sh")
+	if err := os.WriteFile(lib, []byte("echo sourced\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := New("echo before\nsource " + lib).WithFSRoot(root).ExecOutput()
+	perr := requirePolicyError(t, err, "read", reasonOutsideRoot)
+	if perr.Pos.Line() != 2 || perr.Pos.Col() != 1 {
+		t.Errorf("expected position 2:1, got %s", perr.Pos)
+	}
+	if strings.Contains(string(output), "sourced") {
+		t.Errorf("source outside root should be rejected: %q", output)
+	}
+}
+
+func TestFSRootGlob(t *testing.T) {
+	root, outside := newSandboxDir(t)
+
+	err := New("echo " + outside + "/*").WithFSRoot(root).Exec()
+	requirePolicyError(t, err, "readdir", reasonOutsideRoot)
+}
+
+func TestFSRootSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires privileges on Windows")
+	}
+	root, outside := newSandboxDir(t)
+	if err := os.Symlink(outside, filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	err := New(`cat < link/secret.txt`).WithFSRoot(root).WithDir(root).Exec()
+	requirePolicyError(t, err, "read", reasonOutsideRoot)
+}
+
+func TestFSRootSymlinkDotDot(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("creating symlinks requires privileges on Windows")
+	}
+	root, outside := newSandboxDir(t)
+	if err := os.MkdirAll(filepath.Join(outside, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// link/.. resolves to outside, not to root
+	if err := os.Symlink(filepath.Join(outside, "sub"), filepath.Join(root, "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		script string
+		op     string
+	}{
+		{name: "absolute", script: "echo pwned > " + root + "/link/../pwn", op: "write"},
+		{name: "relative", script: "echo pwned > link/../pwn", op: "write"},
+		{name: "glob", script: "echo link/../*", op: "readdir"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(tt.script).WithFSRoot(root).WithDir(root).Exec()
+			requirePolicyError(t, err, tt.op, reasonOutsideRoot)
+			if _, err := os.Stat(filepath.Join(outside, "pwn")); !os.IsNotExist(err) {
+				t.Fatalf("file must not be created outside the root: %v", err)
+			}
+		})
+	}
+}
+
+func TestFSAllow(t *testing.T) {
+	root, outside := newSandboxDir(t)
+	secret := filepath.Join(outside, "secret.txt")
+
+	output, err := New("cat < " + secret + "; echo x > /dev/null").
+		WithFSRoot(root).
+		WithFSAllow(outside).
+		ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if string(output) != "secret\n" {
+		t.Fatalf("unexpected output: %q", output)
+	}
+}
+
+func TestFSRootStartDir(t *testing.T) {
+	root, outside := newSandboxDir(t)
+
+	output, err := New(`echo "$PWD"`).WithFSRoot(root).WithDir(outside).ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != root {
+		t.Fatalf("expected to start at root %q, got %q", root, got)
+	}
+}
+
+func TestFSRootInvalid(t *testing.T) {
+	root, _ := newSandboxDir(t)
+
+	err := New("echo hi").WithFSRoot(filepath.Join(root, "missing")).Exec()
+	if err == nil || !strings.Contains(err.Error(), "invalid filesystem root") {
+		t.Fatalf("expected invalid root error, got %v", err)
+	}
+
+	err = New("echo hi").WithFSRoot(filepath.Join(root, "in.txt")).Exec()
+	if err == nil || !strings.Contains(err.Error(), "not a directory") {
+		t.Fatalf("expected not a directory error, got %v", err)
+	}
+}
+
+func TestReadOnlyFS(t *testing.T) {
+	root, _ := newSandboxDir(t)
+
+	tests := []struct {
+		name   string
+		script string
+	}{
+		{"create", `echo x > new.txt`},
+		{"truncate", `: > in.txt`},
+		{"append", `echo x >> in.txt`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(tt.script).WithDir(root).WithReadOnlyFS().Exec()
+			requirePolicyError(t, err, "write", reasonReadOnly)
+		})
+	}
+
+	// 读取和写入 /dev/null 不受影响
+	output, err := New(`cat < in.txt; echo discarded > /dev/null`).WithDir(root).WithReadOnlyFS().ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if string(output) != "inside\n" {
+		t.Fatalf("unexpected output: %q", output)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "in.txt"))
+	if err != nil || string(data) != "inside\n" {
+		t.Fatalf("file should not be modified: %q, %v", data, err)
+	}
+}
+
+func TestFSPolicyErrorMessage(t *testing.T) {
+	err := &FSPolicyError{Op: "write", Path: "/etc/passwd", Reason: reasonReadOnly}
+	if got := err.Error(); got != "write /etc/passwd: read-only filesystem" {
+		t.Fatalf("unexpected message: %q", got)
+	}
+}
@@ -118,7 +118,8 @@ func TestScriptWithTimeoutActual(t *testing.T) {
 	scriptPath, cleanup := createTempScript(t, "ping -n 10 127.0.0.1 > nul")
 	defer cleanup()
 
-	cmd := NewScript(scriptPath).WithTimeout(50 * time.Millisecond)
+	// 在脚本所在的临时目录中执行, 避免重定向在包目录下创建 nul 文件
+	cmd := NewScript(scriptPath).WithDir(filepath.Dir(scriptPath)).WithTimeout(50 * time.Millisecond)
 
 	err := cmd.Exec()
 	if err == nil {
@@ -243,3 +244,120 @@ func TestScriptWithBinBash(t *testing.T) {
 		t.Fatalf("expected 'bash matched' in output, got: %s", buf.String())
 	}
 }
+
+func TestScriptArgs(t *testing.T) {
+	scriptPath, cleanup := createTempScript(t, `echo "$#"
+for a in "$@"; do
+	echo "[$a]"
+done
+`)
+	defer cleanup()
+
+	output, err := OutScriptArgs(scriptPath, "a b", "", "-c")
+	if err != nil {
+		t.Fatalf("OutScriptArgs failed: %v", err)
+	}
+
+	want := "3\n[a b]\n[]\n[-c]\n"
+	if string(output) != want {
+		t.Fatalf("expected %q, got %q", want, output)
+	}
+
+	if err := RunScriptArgs(scriptPath, "x"); err != nil {
+		t.Fatalf("RunScriptArgs failed: %v", err)
+	}
+}
+
+func TestScriptArg0(t *testing.T) {
+	scriptPath, cleanup := createTempScript(t, `echo "$0"`)
+	defer cleanup()
+
+	output, err := NewScript(scriptPath).ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != scriptPath {
+		t.Fatalf("expected default $0 %q, got %q", scriptPath, got)
+	}
+
+	output, err = NewScript(scriptPath).WithArg0("deploy").ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "deploy" {
+		t.Fatalf("expected $0 %q, got %q", "deploy", got)
+	}
+
+	output, err = New(`echo "$0 $1"`).WithArg0("cmd").WithArgs("one").ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "cmd one" {
+		t.Fatalf("expected %q, got %q", "cmd one", got)
+	}
+}
+
+func TestScriptArgsShift(t *testing.T) {
+	scriptPath, cleanup := createTempScript(t, `first=$1
+shift
+echo "$first|$#|$*"
+shift 2
+echo "$#|$1"
+`)
+	defer cleanup()
+
+	output, err := OutScriptArgs(scriptPath, "a", "b", "c", "d")
+	if err != nil {
+		t.Fatalf("OutScriptArgs failed: %v", err)
+	}
+
+	want := "a|3|b c d\n1|d\n"
+	if string(output) != want {
+		t.Fatalf("expected %q, got %q", want, output)
+	}
+}
+
+func TestScriptArgsGetopts(t *testing.T) {
+	scriptPath, cleanup := createTempScript(t, `verbose=0
+name=
+while getopts "vn:" opt; do
+	case $opt in
+	v) verbose=1 ;;
+	n) name=$OPTARG ;;
+	*) exit 2 ;;
+	esac
+done
+shift $((OPTIND - 1))
+echo "verbose=$verbose name=$name rest=$*"
+`)
+	defer cleanup()
+
+	output, err := OutScriptArgs(scriptPath, "-v", "-n", "demo", "file1", "file2")
+	if err != nil {
+		t.Fatalf("OutScriptArgs failed: %v", err)
+	}
+
+	want := "verbose=1 name=demo rest=file1 file2\n"
+	if string(output) != want {
+		t.Fatalf("expected %q, got %q", want, output)
+	}
+
+	_, err = OutScriptArgs(scriptPath, "-x")
+	if code, ok := IsExitStatus(err); !ok || code != 2 {
+		t.Fatalf("expected exit status 2 for unknown option, got %v", err)
+	}
+}
+
+func TestWithArgsCopy(t *testing.T) {
+	args := []string{"a", "b"}
+	cmd := New(`echo "$1"`).WithArgs(args...)
+	args[0] = "changed"
+
+	output, err := cmd.ExecOutput()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "a" {
+		t.Fatalf("expected %q, got %q", "a", got)
+	}
+}
@@ -52,9 +52,12 @@ import (
 // Shx 表示一个待执行的 shell 命令
 type Shx struct {
 	// 命令配置
-	raw        string         // 原始命令字符串
-	parser     *syntax.Parser // 语法解析器 (可自定义)
-	scriptFile string         // 脚本文件路径 (空=执行命令字符串, 非空=执行脚本文件)
+	raw        string                 // 原始命令字符串
+	parser     *syntax.Parser         // 语法解析器 (可自定义)
+	scriptFile string                 // 脚本文件路径 (空=执行命令字符串, 非空=执行脚本文件)
+	args       []string               // 位置参数 ($1..$n)
+	arg0       string                 // $0 (空=使用默认值)
+	builtins   map[string]BuiltinFunc // 注册为命令的 Go 函数
 
 	// 执行环境
 	dir    string         // 工作目录
@@ -63,6 +66,11 @@ type Shx struct {
 	stdout io.Writer      // 标准输出
 	stderr io.Writer      // 标准错误
 
+	// 文件系统策略
+	fsRoot     string   // 根目录 (空=不限制)
+	fsReadOnly bool     // 是否禁止写入
+	fsAllow    []string // 根目录之外允许访问的路径
+
 	// 上下文和超时
 	ctx     context.Context    // 用户上下文
 	timeout time.Duration      // 超时时间
@@ -72,7 +80,7 @@ type Shx struct {
 	executed atomic.Bool // 是否已执行
 }
 
-// ExitStatus 包装退出状态错误
+// ExitStatus 包装退出状态错误, 也用于 BuiltinFunc 返回退出码
 type ExitStatus struct {
 	Code uint8
 }
@@ -81,3 +89,37 @@ type ExitStatus struct {
 func (e ExitStatus) Error() string {
 	return fmt.Sprintf("exit status %d", e.Code)
 }
+
+// BuiltinFunc 注册为 shell 命令的 Go 函数
+//
+// 参数:
+//   - ctx: 执行上下文, 包含当前的标准输入输出、环境变量和工作目录
+//   - args: 命令参数, 不包含命令名
+//
+// 返回:
+//   - error: nil 表示退出码为 0; 返回 ExitStatus 设置退出码; 返回 ctx.Err() 时终止执行并返回取消或超时错误;
+//     其他错误会以 "命令名: 错误信息" 的形式写入标准错误, 退出码为 1
+type BuiltinFunc func(ctx HandlerContext, args []string) error
+
+// HandlerContext Go 函数命令的执行上下文
+type HandlerContext struct {
+	context.Context // 执行上下文, 命令被取消或超时时取消
+
+	Name   string         // 命令名
+	Stdin  io.Reader      // 标准输入 (已应用重定向和管道)
+	Stdout io.Writer      // 标准输出 (已应用重定向和管道)
+	Stderr io.Writer      // 标准错误 (已应用重定向和管道)
+	Env    expand.Environ // 环境变量, 包含脚本中定义的变量 (只读)
+	Dir    string         // 当前工作目录, 包含脚本中 cd 的结果
+}
+
+// Getenv 获取变量的值
+//
+// 参数:
+//   - name: 变量名
+//
+// 返回:
+//   - string: 变量的值, 未定义时为空字符串
+func (c HandlerContext) Getenv(name string) string {
+	return c.Env.Get(name).String()
+}
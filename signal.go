@@ -0,0 +1,54 @@
+// Package shellx 信号转发模块
+// 本文件实现了 WithSignalForwarding 的信号转发，包括：
+//   - forwardSignals: 进程启动后通过 signal.Notify 接收父进程的信号并转发给子进程
+//   - 停止转发：命令结束时通过 signal.Stop 注销，恢复之前的信号处理状态
+package shellx
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// startSignalForwarding 开始将父进程收到的信号转发给子进程
+//
+// 注意:
+//   - 需要在进程启动之后调用, 停止函数保存在c.stopForward中, 由finish调用
+func (c *Command) startSignalForwarding() {
+	if len(c.forwardSignals) == 0 || c.process == nil {
+		return
+	}
+
+	proc := c.process
+	ch := make(chan os.Signal, len(c.forwardSignals))
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	signal.Notify(ch, c.forwardSignals...)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case sig := <-ch:
+				_ = proc.Signal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	c.stopForward = func() {
+		signal.Stop(ch)
+		close(done)
+		wg.Wait()
+	}
+}
+
+// stopSignalForwarding 停止信号转发
+func (c *Command) stopSignalForwarding() {
+	if c.stopForward != nil {
+		c.stopForward()
+		c.stopForward = nil
+	}
+}
@@ -0,0 +1,50 @@
+//go:build unix
+
+package shellx
+
+import (
+	"os/signal"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestWithSignalForwarding 测试父进程收到的信号转发给子进程
+func TestWithSignalForwarding(t *testing.T) {
+	var out lockedBuffer
+	cmd := NewCmdStr("trap 'echo got; exit 0' USR1; echo ready; sleep 5 & wait").
+		WithStdout(&out).
+		WithKillStrategy(KillStrategy{Group: true}).
+		WithSignalForwarding(syscall.SIGUSR1)
+
+	if err := cmd.ExecAsync(); err != nil {
+		t.Fatalf("启动命令失败: %v", err)
+	}
+
+	// 等待子进程设置好trap
+	deadline := time.Now().Add(3 * time.Second)
+	for !strings.Contains(string(out.Bytes()), "ready") && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("发送信号失败: %v", err)
+	}
+
+	begin := time.Now()
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("期望子进程处理信号后正常退出, 实际错误: %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 3*time.Second {
+		t.Errorf("信号应被转发, 实际耗时 %v", elapsed)
+	}
+	if !strings.Contains(string(out.Bytes()), "got") {
+		t.Errorf("子进程应收到转发的信号, 输出: %q", out.Bytes())
+	}
+
+	// 结束后应恢复之前的信号处理状态
+	if signal.Ignored(syscall.SIGUSR1) {
+		t.Error("转发结束后信号不应被忽略")
+	}
+}
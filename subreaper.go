@@ -0,0 +1,188 @@
+// Package shellx 子进程收割模块
+// 本文件实现了可选的子进程收割 (child subreaper) 模式，包括：
+//   - EnableSubreaper: 将当前进程设置为子进程收割者，双重fork产生的孤儿进程会被挂到当前进程下
+//   - 收割协程：收到SIGCHLD时回收孤儿僵尸进程，不会与shellx或os/exec启动的命令的Wait竞争
+//   - Orphans/KillOrphans: 列出并终止被收养的孤儿进程，可按命令过滤
+//
+// 通过 OSExecutor 启动的进程都会被登记，收割协程只回收带有命令孤儿标记、未登记的僵尸进程。
+// 仅支持 Linux，其他平台返回 errors.ErrUnsupported。
+package shellx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+)
+
+// orphanEnvKey 启用收割模式后注入到命令环境变量中的标记, 用于识别孤儿进程属于哪个命令
+const orphanEnvKey = "SHELLX_JOB_ID"
+
+// ErrSubreaperDisabled 表示尚未启用子进程收割模式
+var ErrSubreaperDisabled = errors.New("child subreaper is not enabled")
+
+// children 通过 OSExecutor 启动的子进程登记表
+var children struct {
+	mu      sync.RWMutex // 启动进程时持有读锁, 收割协程确认子进程是否登记时持有写锁, 避免回收刚启动还未登记的进程
+	tracked sync.Map     // 已登记的进程ID
+}
+
+var (
+	subreaperOn  atomic.Bool  // 是否已启用收割模式
+	subreaperMu  sync.Mutex   // 保护启用过程
+	orphanTokens atomic.Int64 // 孤儿标记序号
+)
+
+// startTracked 启动命令并登记进程ID
+//
+// 参数:
+//   - cmd: exec.Cmd对象
+//
+// 返回:
+//   - error: 启动错误
+func startTracked(cmd *exec.Cmd) error {
+	children.mu.RLock()
+	defer children.mu.RUnlock()
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	children.tracked.Store(cmd.Process.Pid, struct{}{})
+	return nil
+}
+
+// untrack 进程被回收后取消登记
+func untrack(pid int) {
+	children.tracked.Delete(pid)
+}
+
+// isTracked 判断进程是否由shellx启动
+func isTracked(pid int) bool {
+	_, ok := children.tracked.Load(pid)
+	return ok
+}
+
+// EnableSubreaper 将当前进程设置为子进程收割者并启动收割协程
+//
+// 返回:
+//   - error: 设置失败时返回错误, 非Linux平台返回errors.ErrUnsupported
+//
+// 注意:
+//   - 启用后无法关闭, 多次调用只会生效一次
+//   - 之后启动的命令会注入环境变量SHELLX_JOB_ID, 用于按命令识别孤儿进程
+//   - 收割协程只回收由shellx命令派生的孤儿进程, 不影响直接使用os/exec启动的子进程
+//   - 孤儿进程需要在存活期间被收割协程扫描到 (收到SIGCHLD时扫描), 被收养后立即退出的进程可能残留为僵尸进程
+func EnableSubreaper() error {
+	subreaperMu.Lock()
+	defer subreaperMu.Unlock()
+
+	if subreaperOn.Load() {
+		return nil
+	}
+	if err := setSubreaper(); err != nil {
+		return err
+	}
+
+	subreaperOn.Store(true)
+	go reapLoop()
+	return nil
+}
+
+// SubreaperEnabled 判断是否已启用子进程收割模式
+func SubreaperEnabled() bool {
+	return subreaperOn.Load()
+}
+
+// Orphans 列出被当前进程收养的所有存活孤儿进程
+//
+// 返回:
+//   - []int: 孤儿进程ID, 按ID升序排列
+//   - error: 未启用收割模式时返回ErrSubreaperDisabled
+func Orphans() ([]int, error) {
+	if !SubreaperEnabled() {
+		return nil, ErrSubreaperDisabled
+	}
+	return listOrphans("")
+}
+
+// KillOrphans 向所有被收养的孤儿进程及其后代发送信号
+//
+// 参数:
+//   - sig: 信号, 为nil时使用SIGKILL
+//
+// 返回:
+//   - error: 未启用收割模式时返回ErrSubreaperDisabled, 有进程未退出时返回聚合的*TreeKillError
+func KillOrphans(sig os.Signal) error {
+	orphans, err := Orphans()
+	if err != nil {
+		return err
+	}
+	return killOrphans(orphans, sig)
+}
+
+// Orphans 列出由该命令派生、被当前进程收养的存活孤儿进程
+//
+// 返回:
+//   - []int: 孤儿进程ID, 按ID升序排列
+//   - error: 命令启动时未启用收割模式返回ErrSubreaperDisabled
+//
+// 注意:
+//   - 通过环境变量识别, 清空或修改了环境变量的后代进程无法识别
+func (c *Command) Orphans() ([]int, error) {
+	if c.orphanToken == "" {
+		return nil, ErrSubreaperDisabled
+	}
+	return listOrphans(c.orphanToken)
+}
+
+// KillOrphans 向由该命令派生的孤儿进程及其后代发送信号
+//
+// 参数:
+//   - sig: 信号, 为nil时使用SIGKILL
+//
+// 返回:
+//   - error: 命令启动时未启用收割模式返回ErrSubreaperDisabled, 有进程未退出时返回聚合的*TreeKillError
+//
+// 示例:
+//
+//	_ = cmd.Wait()
+//	_ = cmd.KillOrphans(nil) // 清理命令退出后遗留的守护进程
+func (c *Command) KillOrphans(sig os.Signal) error {
+	orphans, err := c.Orphans()
+	if err != nil {
+		return err
+	}
+	return killOrphans(orphans, sig)
+}
+
+// newOrphanToken 生成命令的孤儿标记
+func newOrphanToken() string {
+	return fmt.Sprintf("%d-%d", os.Getpid(), orphanTokens.Add(1))
+}
+
+// killOrphans 终止孤儿进程树
+//
+// 注意:
+//   - 确认退出后立即回收孤儿进程及其后代, 不依赖收割协程是否扫描到过这些进程
+func killOrphans(orphans []int, sig os.Signal) error {
+	var errs []error
+	for _, pid := range orphans {
+		// 孤儿进程的后代在其退出后同样会被当前进程收养
+		pids := []int{pid}
+		if tree, err := ProcessTree(pid); err == nil {
+			pids = tree.PIDs()
+		}
+
+		err := KillTreeWith(pid, sig, TreeKillOptions{AllAtOnce: true})
+		if err != nil && !errors.Is(err, os.ErrProcessDone) {
+			errs = append(errs, err)
+			continue
+		}
+		for _, p := range pids {
+			reapOrphan(p)
+		}
+	}
+	return errors.Join(errs...)
+}
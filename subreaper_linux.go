@@ -0,0 +1,224 @@
+//go:build linux
+
+package shellx
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+)
+
+// prSetChildSubreaper prctl设置子进程收割者的选项
+const prSetChildSubreaper = 36
+
+// setSubreaper 通过prctl将当前进程设置为子进程收割者
+func setSubreaper() error {
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0); errno != 0 {
+		return fmt.Errorf("prctl(PR_SET_CHILD_SUBREAPER): %w", errno)
+	}
+	return nil
+}
+
+// knownOrphans 已知的孤儿进程, 包括尚未被收养的命令后代进程, 只有其中的进程会被回收
+var knownOrphans = struct {
+	mu    sync.Mutex
+	procs procSet
+}{procs: make(procSet)}
+
+// reapLoop 收到SIGCHLD时回收孤儿僵尸进程
+func reapLoop() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGCHLD)
+
+	for range sigs {
+		reapOrphans()
+	}
+}
+
+// reapOrphans 记录存活的孤儿进程, 并回收其中已经退出的进程
+//
+// 注意:
+//   - 只回收已知的孤儿进程, 不会回收其他代码通过os/exec启动的子进程
+//   - 孤儿进程在收割协程扫描、listOrphans 或 killOrphans 时被记录;
+//     僵尸进程无法读取环境变量, 从未被记录过就已退出的孤儿进程由内核在当前进程退出后处理
+//   - 扫描 /proc 时不持有登记表的锁, 只在确认直接子进程是否已登记时短暂持有写锁
+func reapOrphans() {
+	procs, err := scanProcs()
+	if err != nil {
+		return
+	}
+
+	self := os.Getpid()
+	rememberOrphans(slices.Collect(maps.Keys(descendants(self, procs))), procs)
+
+	knownOrphans.mu.Lock()
+	defer knownOrphans.mu.Unlock()
+
+	for pid, start := range knownOrphans.procs {
+		p, ok := procs[pid]
+		switch {
+		case !ok || p.start != start:
+			// 已经退出并被回收, 其ID可能已被复用
+			delete(knownOrphans.procs, pid)
+		case p.ppid == self && p.state == "Z":
+			var ws syscall.WaitStatus
+			if _, err := syscall.Wait4(pid, &ws, syscall.WNOHANG, nil); err == nil {
+				delete(knownOrphans.procs, pid)
+			}
+		}
+	}
+}
+
+// rememberOrphans 记录带有当前进程孤儿标记的存活进程
+//
+// 参数:
+//   - pids: 候选进程ID
+//   - procs: /proc 扫描结果
+//
+// 注意:
+//   - 直接子进程可能是刚启动还未登记的命令, 持有登记表写锁等待正在进行的启动完成后再确认
+func rememberOrphans(pids []int, procs map[int]procEntry) {
+	self := os.Getpid()
+	marker := []byte(orphanEnvKey + "=" + strconv.Itoa(self) + "-")
+
+	var direct, nested []int
+	for _, pid := range pids {
+		p, ok := procs[pid]
+		if !ok || p.state == "Z" {
+			continue
+		}
+		knownOrphans.mu.Lock()
+		start, known := knownOrphans.procs[pid]
+		knownOrphans.mu.Unlock()
+		if (known && start == p.start) || !hasEnvPrefix(pid, marker) {
+			continue
+		}
+		if p.ppid == self {
+			direct = append(direct, pid)
+		} else {
+			nested = append(nested, pid)
+		}
+	}
+
+	if len(direct) > 0 {
+		children.mu.Lock()
+		direct = slices.DeleteFunc(direct, isTracked)
+		children.mu.Unlock()
+	}
+
+	knownOrphans.mu.Lock()
+	defer knownOrphans.mu.Unlock()
+	for _, pid := range slices.Concat(direct, nested) {
+		knownOrphans.procs[pid] = procs[pid].start
+	}
+}
+
+// reapOrphan 回收已经退出的已知孤儿进程
+//
+// 参数:
+//   - pid: 进程ID
+//
+// 注意:
+//   - 进程不是已知的孤儿进程或仍在运行时不做任何操作
+func reapOrphan(pid int) {
+	knownOrphans.mu.Lock()
+	defer knownOrphans.mu.Unlock()
+
+	start, ok := knownOrphans.procs[pid]
+	if !ok {
+		return
+	}
+	st, err := readProcStat(pid)
+	if err != nil || st.start != start {
+		delete(knownOrphans.procs, pid)
+		return
+	}
+	if st.ppid != os.Getpid() || st.state != "Z" {
+		return
+	}
+
+	var ws syscall.WaitStatus
+	if _, err := syscall.Wait4(pid, &ws, syscall.WNOHANG, nil); err == nil {
+		delete(knownOrphans.procs, pid)
+	}
+}
+
+// descendants 返回指定进程的所有后代进程ID
+func descendants(pid int, procs map[int]procEntry) map[int]bool {
+	index := childrenIndex(procs)
+	result := make(map[int]bool)
+	stack := slices.Clone(index[pid])
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if result[p] {
+			continue
+		}
+		result[p] = true
+		stack = append(stack, index[p]...)
+	}
+	return result
+}
+
+// listOrphans 列出被收养的存活孤儿进程
+//
+// 参数:
+//   - token: 命令的孤儿标记, 为空时返回所有孤儿进程
+//
+// 返回:
+//   - []int: 孤儿进程ID, 按ID升序排列
+//   - error: 读取 /proc 失败时返回错误
+func listOrphans(token string) ([]int, error) {
+	procs, err := scanProcs()
+	if err != nil {
+		return nil, err
+	}
+
+	self := os.Getpid()
+	marker := []byte(orphanEnvKey + "=" + token)
+	var orphans []int
+	for pid, p := range procs {
+		if p.ppid != self || p.state == "Z" || isTracked(pid) {
+			continue
+		}
+		if token != "" && !hasEnv(pid, marker) {
+			continue
+		}
+		orphans = append(orphans, pid)
+	}
+	slices.Sort(orphans)
+
+	// 记录孤儿进程及其后代, 收养不会产生SIGCHLD, 收割协程可能从未扫描到这些进程
+	rememberOrphans(slices.Collect(maps.Keys(descendants(self, procs))), procs)
+	return orphans, nil
+}
+
+// hasEnv 判断进程的环境变量中是否包含指定的"key=value"
+func hasEnv(pid int, env []byte) bool {
+	return matchEnv(pid, func(entry []byte) bool { return bytes.Equal(entry, env) })
+}
+
+// hasEnvPrefix 判断进程的环境变量中是否有以指定前缀开头的条目
+func hasEnvPrefix(pid int, prefix []byte) bool {
+	return matchEnv(pid, func(entry []byte) bool { return bytes.HasPrefix(entry, prefix) })
+}
+
+// matchEnv 判断进程的环境变量中是否有满足条件的条目
+func matchEnv(pid int, match func(entry []byte) bool) bool {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid))
+	if err != nil {
+		return false
+	}
+	for entry := range bytes.SplitSeq(data, []byte{0}) {
+		if match(entry) {
+			return true
+		}
+	}
+	return false
+}
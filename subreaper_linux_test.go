@@ -0,0 +1,133 @@
+//go:build linux
+
+package shellx
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+)
+
+// waitOrphans 等待命令的孤儿进程数量达到指定值
+func waitOrphans(t *testing.T, c *Command, n int) []int {
+	t.Helper()
+
+	var orphans []int
+	for range 200 {
+		var err error
+		if orphans, err = c.Orphans(); err != nil {
+			t.Fatalf("列出孤儿进程失败: %v", err)
+		}
+		if len(orphans) == n {
+			return orphans
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("期望 %d 个孤儿进程, 实际为 %v", n, orphans)
+	return nil
+}
+
+// subreaperTestEnv 标记在单独的子进程中运行收割模式测试
+const subreaperTestEnv = "SHELLX_TEST_SUBREAPER"
+
+// TestSubreaper 测试子进程收割模式
+func TestSubreaper(t *testing.T) {
+	// 收割模式启用后无法关闭且作用于整个进程, 重新执行测试程序在子进程中运行
+	if os.Getenv(subreaperTestEnv) != "1" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestSubreaper$", "-test.v")
+		cmd.Env = append(os.Environ(), subreaperTestEnv+"=1")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("子进程中的测试失败: %v\n%s", err, out)
+		}
+		t.Logf("子进程中的测试输出:\n%s", out)
+		return
+	}
+
+	t.Run("未启用时返回错误", func(t *testing.T) {
+		c := NewCmdStr("true")
+		if err := c.Exec(); err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		if _, err := c.Orphans(); !errors.Is(err, ErrSubreaperDisabled) {
+			t.Fatalf("期望 ErrSubreaperDisabled, 实际为 %v", err)
+		}
+	})
+
+	if err := EnableSubreaper(); err != nil {
+		t.Skipf("无法启用子进程收割模式: %v", err)
+	}
+	if !SubreaperEnabled() {
+		t.Fatal("期望收割模式已启用")
+	}
+
+	t.Run("收养并终止孤儿进程", func(t *testing.T) {
+		c := NewCmdStr("(sleep 30 &)")
+		if err := c.Exec(); err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+
+		orphans := waitOrphans(t, c, 1)
+		all, err := Orphans()
+		if err != nil {
+			t.Fatalf("列出孤儿进程失败: %v", err)
+		}
+		if !slices.Contains(all, orphans[0]) {
+			t.Fatalf("期望全部孤儿进程 %v 包含 %d", all, orphans[0])
+		}
+
+		// 其他命令不应该认领该孤儿进程
+		other := NewCmdStr("true")
+		if err := other.Exec(); err != nil {
+			t.Fatalf("执行失败: %v", err)
+		}
+		if got, _ := other.Orphans(); len(got) != 0 {
+			t.Fatalf("期望其他命令没有孤儿进程, 实际为 %v", got)
+		}
+
+		if err := c.KillOrphans(nil); err != nil {
+			t.Fatalf("终止孤儿进程失败: %v", err)
+		}
+
+		// KillOrphans 返回时孤儿进程已经被回收, 不依赖收割协程是否扫描到过该进程
+		if _, err := readProcStat(orphans[0]); err == nil {
+			t.Fatalf("孤儿进程 %d 没有被回收", orphans[0])
+		}
+		if got, _ := c.Orphans(); len(got) != 0 {
+			t.Fatalf("期望没有孤儿进程, 实际为 %v", got)
+		}
+	})
+
+	t.Run("不回收os/exec启动的子进程", func(t *testing.T) {
+		for range 20 {
+			c := exec.Command("sh", "-c", "exit 3")
+			if err := c.Start(); err != nil {
+				t.Fatalf("启动失败: %v", err)
+			}
+			time.Sleep(20 * time.Millisecond)
+			// 触发收割协程扫描
+			if err := NewCmdStr("true").Exec(); err != nil {
+				t.Fatalf("执行失败: %v", err)
+			}
+			var exitErr *exec.ExitError
+			if err := c.Wait(); !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+				t.Fatalf("期望退出码 3, 实际错误为 %v", err)
+			}
+		}
+	})
+
+	t.Run("不影响命令自身的Wait", func(t *testing.T) {
+		for range 50 {
+			c := NewCmdStr("exit 3")
+			if err := c.ExecAsync(); err != nil {
+				t.Fatalf("启动失败: %v", err)
+			}
+			if code, _ := c.WaitWithCode(); code != 3 {
+				t.Fatalf("期望退出码 3, 实际为 %d", code)
+			}
+		}
+	})
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package shellx
+
+import (
+	"errors"
+)
+
+// setSubreaper 非Linux平台不支持子进程收割者
+func setSubreaper() error {
+	return errors.ErrUnsupported
+}
+
+// reapLoop 非Linux平台不支持子进程收割者
+func reapLoop() {}
+
+// listOrphans 非Linux平台不支持子进程收割者
+func listOrphans(token string) ([]int, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// reapOrphan 非Linux平台不支持子进程收割者
+func reapOrphan(pid int) {}
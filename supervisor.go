@@ -0,0 +1,479 @@
+// Package shellx 进程守护模块
+// 本文件定义了 Supervisor 结构体，用于守护长期运行的命令并在其退出后自动重启，支持：
+//   - 重启策略：总是重启、失败时重启、从不重启
+//   - 重启限制：时间窗口内的最大重启次数，超过后进入失败状态
+//   - 指数退避：重启间隔按倍数增长，不超过最大间隔
+//   - 存活检查：定期检查进程状态，连续失败达到阈值后重启进程
+//   - 状态通知：通过回调或通道获取状态变化 (starting、running、backoff、stopped、failed)
+//   - 稳定重置：命令稳定运行一段时间后重新计算退避
+//   - 优雅停止：上下文取消时按命令的终止策略终止进程，未设置时先发送SIGTERM
+package shellx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// 守护进程的默认配置
+const (
+	defaultInitialBackoff    = time.Second      // 默认初始退避时间
+	defaultMaxBackoff        = 30 * time.Second // 默认最大退避时间
+	defaultBackoffMultiplier = 2.0              // 默认退避倍数
+	defaultLivenessInterval  = 10 * time.Second // 默认存活检查间隔
+	defaultLivenessThreshold = 3                // 默认存活检查连续失败阈值
+	defaultStopGracePeriod   = 10 * time.Second // 默认停止时等待进程退出的时间
+	supervisorEventBuffer    = 64               // 状态通道的缓冲大小
+)
+
+var (
+	// ErrTooManyRestarts 表示命令在时间窗口内的重启次数超过上限
+	ErrTooManyRestarts = errors.New("too many restarts")
+	// ErrLivenessFailed 表示存活检查连续失败
+	ErrLivenessFailed = errors.New("liveness check failed")
+	// ErrSupervisorRunning 表示守护进程已经在运行
+	ErrSupervisorRunning = errors.New("supervisor is already running")
+)
+
+// RestartMode 重启模式
+type RestartMode int
+
+const (
+	RestartOnFailure RestartMode = iota // 仅在命令失败时重启 (默认)
+	RestartAlways                       // 无论命令是否成功都重启
+	RestartNever                        // 从不重启
+)
+
+// String 返回重启模式的字符串表示
+func (m RestartMode) String() string {
+	switch m {
+	case RestartOnFailure:
+		return "on-failure"
+
+	case RestartAlways:
+		return "always"
+
+	case RestartNever:
+		return "never"
+
+	default:
+		return "unknown"
+	}
+}
+
+// RestartPolicy 重启策略
+type RestartPolicy struct {
+	Mode           RestartMode   // 重启模式
+	MaxRestarts    int           // 时间窗口内的最大重启次数, 小于等于0表示不限制
+	Window         time.Duration // 统计重启次数的时间窗口, 小于等于0表示统计上次稳定运行以来的重启
+	InitialBackoff time.Duration // 初始退避时间, 小于等于0时使用1s
+	MaxBackoff     time.Duration // 最大退避时间, 小于等于0时使用30s
+	Multiplier     float64       // 退避倍数, 小于1时使用2
+	StableAfter    time.Duration // 命令运行超过该时间视为稳定, 重新计算退避, 小于等于0时使用最大退避时间
+}
+
+// stableAfter 返回命令视为稳定运行所需的时间
+func (p RestartPolicy) stableAfter() time.Duration {
+	if p.StableAfter > 0 {
+		return p.StableAfter
+	}
+	if p.MaxBackoff > 0 {
+		return p.MaxBackoff
+	}
+	return defaultMaxBackoff
+}
+
+// backoff 计算第n次(从1开始)重启前的退避时间
+func (p RestartPolicy) backoff(n int) time.Duration {
+	initial, maxDelay, mult := p.InitialBackoff, p.MaxBackoff, p.Multiplier
+	if initial <= 0 {
+		initial = defaultInitialBackoff
+	}
+	if maxDelay <= 0 {
+		maxDelay = defaultMaxBackoff
+	}
+	if mult < 1 {
+		mult = defaultBackoffMultiplier
+	}
+
+	d := float64(initial) * math.Pow(mult, float64(n-1))
+	if d > float64(maxDelay) {
+		return maxDelay
+	}
+	return time.Duration(d)
+}
+
+// LivenessCheck 存活检查配置
+type LivenessCheck struct {
+	Check            func(ctx context.Context, pid int) error // 检查函数, 返回错误表示本次检查失败
+	InitialDelay     time.Duration                            // 进程启动后首次检查前的等待时间
+	Interval         time.Duration                            // 检查间隔, 小于等于0时使用10s
+	Timeout          time.Duration                            // 单次检查超时, 小于等于0时使用检查间隔
+	FailureThreshold int                                      // 连续失败多少次后重启进程, 小于等于0时使用3
+}
+
+// SupervisorState 守护进程状态
+type SupervisorState int
+
+const (
+	SupervisorStarting SupervisorState = iota // 正在启动命令
+	SupervisorRunning                         // 命令正在运行
+	SupervisorBackoff                         // 命令已退出, 等待重启
+	SupervisorStopped                         // 已停止 (上下文取消或无需重启)
+	SupervisorFailed                          // 已失败 (重启次数超限或无需重启的失败)
+)
+
+// String 返回守护进程状态的字符串表示
+func (s SupervisorState) String() string {
+	switch s {
+	case SupervisorStarting:
+		return "starting"
+
+	case SupervisorRunning:
+		return "running"
+
+	case SupervisorBackoff:
+		return "backoff"
+
+	case SupervisorStopped:
+		return "stopped"
+
+	case SupervisorFailed:
+		return "failed"
+
+	default:
+		return "unknown"
+	}
+}
+
+// StateChange 守护进程状态变化事件
+type StateChange struct {
+	State    SupervisorState // 新状态
+	PID      int             // 进程ID, 仅running状态有效
+	Restarts int             // 累计重启次数
+	Err      error           // 命令退出的错误, backoff、stopped和failed状态有效
+	Delay    time.Duration   // 重启前的退避时间, 仅backoff状态有效
+	Time     time.Time       // 状态变化时间
+}
+
+// Supervisor 进程守护
+//
+// 注意:
+//   - 配置方法需要在调用 Run 之前完成
+//   - 命令由工厂函数在每次启动时创建, 因为 Command 只能执行一次
+//   - 停止时按命令的终止策略终止进程, 工厂函数未设置 WithKillStrategy 时先发送SIGTERM, 10s后强制杀死
+//     (Windows不支持SIGTERM, 直接强制杀死)
+type Supervisor struct {
+	factory  func() *Command     // 命令工厂函数
+	policy   RestartPolicy       // 重启策略
+	liveness *LivenessCheck      // 存活检查 (nil表示不检查)
+	onChange []func(StateChange) // 状态变化回调
+	events   chan StateChange    // 状态变化通道 (nil表示未启用)
+	running  bool                // 是否正在运行
+	mu       sync.Mutex          // 保护状态字段
+	last     StateChange         // 最近一次状态变化
+}
+
+// NewSupervisor 创建进程守护
+//
+// 参数:
+//   - factory: 命令工厂函数, 每次启动时调用以创建新的命令
+//   - policy: 重启策略
+//
+// 返回:
+//   - *Supervisor: 进程守护对象
+//
+// 示例:
+//
+//	sup := shellx.NewSupervisor(func() *shellx.Command {
+//		return shellx.NewCmd("./sidecar", "--port", "9000").
+//			WithKillStrategy(shellx.KillStrategy{Signal: syscall.SIGTERM, GracePeriod: 5 * time.Second})
+//	}, shellx.RestartPolicy{Mode: shellx.RestartAlways, MaxRestarts: 5, Window: time.Minute})
+//	err := sup.Run(ctx)
+func NewSupervisor(factory func() *Command, policy RestartPolicy) *Supervisor {
+	if factory == nil {
+		panic("shellx: supervisor command factory must not be nil")
+	}
+	return &Supervisor{factory: factory, policy: policy, last: StateChange{State: SupervisorStopped}}
+}
+
+// WithLiveness 设置存活检查
+//
+// 参数:
+//   - check: 存活检查配置, Check为nil时会panic
+//
+// 返回:
+//   - *Supervisor: 进程守护对象
+func (s *Supervisor) WithLiveness(check LivenessCheck) *Supervisor {
+	if check.Check == nil {
+		panic("shellx: liveness check function must not be nil")
+	}
+	s.liveness = &check
+	return s
+}
+
+// OnStateChange 添加状态变化回调
+//
+// 参数:
+//   - fn: 回调函数
+//
+// 返回:
+//   - *Supervisor: 进程守护对象
+//
+// 注意:
+//   - 回调在 Run 所在的协程中同步调用, 不要在回调中长时间阻塞
+func (s *Supervisor) OnStateChange(fn func(StateChange)) *Supervisor {
+	s.onChange = append(s.onChange, fn)
+	return s
+}
+
+// Events 获取状态变化通道
+//
+// 返回:
+//   - <-chan StateChange: 状态变化通道, Run 返回时关闭
+//
+// 注意:
+//   - 通道有缓冲, 缓冲已满时新的事件会被丢弃, 需要完整事件时请使用 OnStateChange
+//   - 需要在调用 Run 之前获取
+func (s *Supervisor) Events() <-chan StateChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.events == nil {
+		s.events = make(chan StateChange, supervisorEventBuffer)
+	}
+	return s.events
+}
+
+// State 获取最近一次状态变化
+//
+// 返回:
+//   - StateChange: 最近一次状态变化, 未运行时为stopped
+func (s *Supervisor) State() StateChange {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Run 启动并守护命令, 阻塞直到守护结束
+//
+// 参数:
+//   - ctx: 上下文, 取消时终止正在运行的命令并停止守护, 为nil时使用context.Background()
+//
+// 返回:
+//   - error: 上下文取消或命令无需重启时返回nil(命令失败且无需重启时返回该错误),
+//     重启次数超限时返回包装了 ErrTooManyRestarts 的错误
+//
+// 注意:
+//   - 同一个守护对象不能并发运行, 否则返回 ErrSupervisorRunning
+func (s *Supervisor) Run(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return ErrSupervisorRunning
+	}
+	s.running = true
+	events := s.events
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.running = false
+		s.events = nil
+		s.mu.Unlock()
+		if events != nil {
+			close(events)
+		}
+	}()
+
+	var (
+		restarts int         // 累计重启次数
+		streak   int         // 上次稳定运行以来的重启次数
+		history  []time.Time // 时间窗口内的重启时间 (仅在设置了时间窗口时记录)
+	)
+
+	for {
+		s.notify(StateChange{State: SupervisorStarting, Restarts: restarts})
+		began := time.Now()
+		err := s.runOnce(ctx, restarts)
+		if time.Since(began) >= s.policy.stableAfter() {
+			streak = 0
+		}
+
+		if ctx.Err() != nil {
+			s.notify(StateChange{State: SupervisorStopped, Restarts: restarts, Err: err})
+			return nil
+		}
+
+		restart := s.policy.Mode == RestartAlways || (s.policy.Mode == RestartOnFailure && err != nil)
+		if !restart {
+			if err != nil {
+				s.notify(StateChange{State: SupervisorFailed, Restarts: restarts, Err: err})
+				return err
+			}
+			s.notify(StateChange{State: SupervisorStopped, Restarts: restarts})
+			return nil
+		}
+
+		// 清理时间窗口之外的重启记录, 没有时间窗口时统计上次稳定运行以来的重启
+		recent := streak
+		if s.policy.Window > 0 {
+			now := time.Now()
+			kept := history[:0]
+			for _, t := range history {
+				if now.Sub(t) < s.policy.Window {
+					kept = append(kept, t)
+				}
+			}
+			history = append(kept, now)
+			recent = len(history) - 1
+		}
+
+		if s.policy.MaxRestarts > 0 && recent >= s.policy.MaxRestarts {
+			failErr := fmt.Errorf("%w: %d restarts", ErrTooManyRestarts, recent)
+			if s.policy.Window > 0 {
+				failErr = fmt.Errorf("%w within %v", failErr, s.policy.Window)
+			}
+			if err != nil {
+				failErr = fmt.Errorf("%w, last error: %w", failErr, err)
+			}
+			s.notify(StateChange{State: SupervisorFailed, Restarts: restarts, Err: failErr})
+			return failErr
+		}
+
+		restarts++
+		streak++
+		delay := s.policy.backoff(recent + 1)
+		s.notify(StateChange{State: SupervisorBackoff, Restarts: restarts, Err: err, Delay: delay})
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			s.notify(StateChange{State: SupervisorStopped, Restarts: restarts, Err: err})
+			return nil
+		}
+	}
+}
+
+// runOnce 启动一次命令并等待其结束
+//
+// 参数:
+//   - ctx: 守护上下文
+//   - restarts: 累计重启次数
+//
+// 返回:
+//   - error: 命令执行错误, 存活检查失败时返回包装了 ErrLivenessFailed 的错误
+func (s *Supervisor) runOnce(ctx context.Context, restarts int) error {
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	cmd := s.factory()
+	cmd.parentCtx = runCtx
+	if cmd.killStrategy == (KillStrategy{}) && runtime.GOOS != "windows" {
+		cmd.killStrategy = KillStrategy{Signal: syscall.SIGTERM, GracePeriod: defaultStopGracePeriod}
+	}
+	if err := cmd.ExecAsync(); err != nil {
+		return err
+	}
+
+	pid := cmd.GetPID()
+	s.notify(StateChange{State: SupervisorRunning, PID: pid, Restarts: restarts})
+
+	var wg sync.WaitGroup
+	if s.liveness != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.probe(runCtx, cancel, pid)
+		}()
+	}
+
+	err := cmd.Wait()
+	cancel(nil)
+	wg.Wait()
+
+	if cause := context.Cause(runCtx); errors.Is(cause, ErrLivenessFailed) {
+		return cause
+	}
+	return err
+}
+
+// probe 定期执行存活检查, 连续失败达到阈值时取消命令
+//
+// 参数:
+//   - ctx: 本次运行的上下文, 命令结束时取消
+//   - cancel: 本次运行的取消函数
+//   - pid: 进程ID
+func (s *Supervisor) probe(ctx context.Context, cancel context.CancelCauseFunc, pid int) {
+	lc := s.liveness
+	interval, threshold := lc.Interval, lc.FailureThreshold
+	if interval <= 0 {
+		interval = defaultLivenessInterval
+	}
+	if threshold <= 0 {
+		threshold = defaultLivenessThreshold
+	}
+	timeout := lc.Timeout
+	if timeout <= 0 {
+		timeout = interval
+	}
+
+	timer := time.NewTimer(lc.InitialDelay)
+	defer timer.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		checkCtx, checkCancel := context.WithTimeout(ctx, timeout)
+		err := lc.Check(checkCtx, pid)
+		checkCancel()
+
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			failures = 0
+		} else if failures++; failures >= threshold {
+			cancel(fmt.Errorf("%w %d times: %w", ErrLivenessFailed, failures, err))
+			return
+		}
+		timer.Reset(interval)
+	}
+}
+
+// notify 记录状态变化并通知回调和通道
+func (s *Supervisor) notify(ev StateChange) {
+	ev.Time = time.Now()
+
+	s.mu.Lock()
+	s.last = ev
+	events := s.events
+	s.mu.Unlock()
+
+	for _, fn := range s.onChange {
+		fn(ev)
+	}
+
+	if events != nil {
+		select {
+		case events <- ev:
+		default:
+		}
+	}
+}
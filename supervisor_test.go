@@ -0,0 +1,215 @@
+package shellx
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stateRecorder 记录守护进程的状态变化
+type stateRecorder struct {
+	mu     sync.Mutex
+	states []SupervisorState
+}
+
+func (r *stateRecorder) record(ev StateChange) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, ev.State)
+}
+
+func (r *stateRecorder) count(state SupervisorState) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, s := range r.states {
+		if s == state {
+			n++
+		}
+	}
+	return n
+}
+
+// TestSupervisorMaxRestarts 测试失败重启次数超限
+func TestSupervisorMaxRestarts(t *testing.T) {
+	rec := &stateRecorder{}
+	sup := NewSupervisor(func() *Command {
+		return NewCmdStr("exit 1")
+	}, RestartPolicy{Mode: RestartOnFailure, MaxRestarts: 2, Window: time.Minute, InitialBackoff: 10 * time.Millisecond}).
+		OnStateChange(rec.record)
+
+	err := sup.Run(context.Background())
+	if !errors.Is(err, ErrTooManyRestarts) {
+		t.Fatalf("期望 ErrTooManyRestarts, 实际为: %v", err)
+	}
+	if n := rec.count(SupervisorStarting); n != 3 {
+		t.Errorf("期望启动3次, 实际为 %d", n)
+	}
+	if n := rec.count(SupervisorBackoff); n != 2 {
+		t.Errorf("期望退避2次, 实际为 %d", n)
+	}
+	if st := sup.State(); st.State != SupervisorFailed || st.Restarts != 2 {
+		t.Errorf("最终状态不符合预期: %+v", st)
+	}
+}
+
+// TestSupervisorNoRestart 测试成功退出时不重启
+func TestSupervisorNoRestart(t *testing.T) {
+	sup := NewSupervisor(func() *Command {
+		return NewCmd("echo", "ok")
+	}, RestartPolicy{Mode: RestartOnFailure})
+
+	events := sup.Events()
+	if err := sup.Run(context.Background()); err != nil {
+		t.Fatalf("期望成功, 实际错误: %v", err)
+	}
+
+	var states []SupervisorState
+	for ev := range events {
+		states = append(states, ev.State)
+	}
+	want := []SupervisorState{SupervisorStarting, SupervisorRunning, SupervisorStopped}
+	if len(states) != len(want) {
+		t.Fatalf("状态序列不符合预期: %v", states)
+	}
+	for i := range want {
+		if states[i] != want[i] {
+			t.Errorf("状态序列不符合预期: %v", states)
+			break
+		}
+	}
+}
+
+// TestSupervisorStop 测试上下文取消时停止守护
+func TestSupervisorStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sup := NewSupervisor(func() *Command {
+		return NewCmd("sleep", "5").WithShell(ShellNone)
+	}, RestartPolicy{Mode: RestartAlways}).
+		OnStateChange(func(ev StateChange) {
+			if ev.State == SupervisorRunning {
+				cancel()
+			}
+		})
+
+	begin := time.Now()
+	if err := sup.Run(ctx); err != nil {
+		t.Errorf("取消后期望返回nil, 实际为: %v", err)
+	}
+	if elapsed := time.Since(begin); elapsed > 3*time.Second {
+		t.Errorf("取消后应尽快停止, 实际耗时 %v", elapsed)
+	}
+	if st := sup.State(); st.State != SupervisorStopped {
+		t.Errorf("期望状态为 stopped, 实际为 %s", st.State)
+	}
+}
+
+// TestSupervisorLiveness 测试存活检查失败时重启
+func TestSupervisorLiveness(t *testing.T) {
+	rec := &stateRecorder{}
+	sup := NewSupervisor(func() *Command {
+		return NewCmd("sleep", "5").WithShell(ShellNone)
+	}, RestartPolicy{Mode: RestartAlways, MaxRestarts: 1, InitialBackoff: 10 * time.Millisecond}).
+		WithLiveness(LivenessCheck{
+			Interval:         20 * time.Millisecond,
+			FailureThreshold: 2,
+			Check: func(ctx context.Context, pid int) error {
+				return errors.New("unhealthy")
+			},
+		}).
+		OnStateChange(rec.record)
+
+	err := sup.Run(context.Background())
+	if !errors.Is(err, ErrTooManyRestarts) || !errors.Is(err, ErrLivenessFailed) {
+		t.Fatalf("期望存活检查失败导致重启超限, 实际为: %v", err)
+	}
+	if n := rec.count(SupervisorRunning); n != 2 {
+		t.Errorf("期望运行2次, 实际为 %d", n)
+	}
+}
+
+// TestSupervisorStableReset 测试命令稳定运行后重新计算退避和重启次数
+func TestSupervisorStableReset(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var delays []time.Duration
+	runs := 0
+	sup := NewSupervisor(func() *Command {
+		if runs++; runs == 5 {
+			cancel()
+		}
+		return NewCmd("sleep", "0.05").WithShell(ShellNone)
+	}, RestartPolicy{
+		Mode:           RestartAlways,
+		MaxRestarts:    2,
+		InitialBackoff: 10 * time.Millisecond,
+		StableAfter:    20 * time.Millisecond,
+	}).
+		OnStateChange(func(ev StateChange) {
+			if ev.State == SupervisorBackoff {
+				delays = append(delays, ev.Delay)
+			}
+		})
+
+	if err := sup.Run(ctx); err != nil {
+		t.Fatalf("稳定运行后不应累计重启次数, 实际错误: %v", err)
+	}
+	if len(delays) != 4 {
+		t.Fatalf("期望退避4次, 实际为 %v", delays)
+	}
+	for _, d := range delays {
+		if d != 10*time.Millisecond {
+			t.Errorf("稳定运行后期望使用初始退避时间, 实际为 %v", delays)
+			break
+		}
+	}
+}
+
+// TestSupervisorStopDefaultSignal 测试未设置终止策略时停止守护先发送SIGTERM
+func TestSupervisorStopDefaultSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows不支持SIGTERM")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var cmd *Command
+	sup := NewSupervisor(func() *Command {
+		cmd = NewCmdStr(`trap 'kill $!; exit 3' TERM; sleep 5 & wait`)
+		return cmd
+	}, RestartPolicy{Mode: RestartAlways}).
+		OnStateChange(func(ev StateChange) {
+			if ev.State == SupervisorRunning {
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					cancel()
+				}()
+			}
+		})
+
+	if err := sup.Run(ctx); err != nil {
+		t.Errorf("取消后期望返回nil, 实际为: %v", err)
+	}
+	if cmd.exitCode != 3 {
+		t.Errorf("期望命令收到SIGTERM后退出, 实际退出码为 %d", cmd.exitCode)
+	}
+}
+
+// TestRestartPolicyBackoff 测试指数退避计算
+func TestRestartPolicyBackoff(t *testing.T) {
+	p := RestartPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 3}
+	want := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second}
+	for i, d := range want {
+		if got := p.backoff(i + 1); got != d {
+			t.Errorf("第%d次退避期望为 %v, 实际为 %v", i+1, d, got)
+		}
+	}
+}
@@ -2,7 +2,9 @@
 // 本文件定义了ShellType枚举，提供了shell类型管理。
 //
 // 主要类型：
-//   - ShellType: Shell类型枚举，支持sh、bash、cmd、powershell等多种shell
+//   - ShellType: Shell类型枚举，支持sh、bash、cmd、powershell等多种shell，可通过 RegisterShell 扩展
+//   - Result: 命令执行结果，包含捕获的输出、退出码和耗时
+//   - KillStrategy: 命令被取消时的终止策略
 //
 // ShellType支持的shell类型：
 //   - ShellSh: Unix/Linux sh shell
@@ -10,6 +12,9 @@
 //   - ShellCmd: Windows Command Prompt
 //   - ShellPowerShell: Windows PowerShell
 //   - ShellPwsh: PowerShell Core (跨平台)
+//   - ShellZsh/ShellFish/ShellDash/ShellKsh/ShellAsh: 其他常见shell, ShellAsh 通过 busybox 执行
+//   - ShellAuto: 按优先级自动选择第一个可用的shell
+//   - 通过 RegisterShell 注册的自定义shell
 //   - ShellNone: 直接执行命令，不使用shell
 //   - ShellDef1: 根据操作系统自动选择默认shell(Windows系统默认为cmd, 其他系统默认为sh)
 //   - ShellDef2: 根据操作系统自动选择默认shell(Windows系统默认为powershell, 其他系统默认为sh)
@@ -20,6 +25,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // ShellType 定义shell类型
@@ -34,82 +40,66 @@ const (
 	ShellNone                        // 无shell, 直接原生的执行命令
 	ShellDef1                        // 默认shell, 根据操作系统自动选择(Windows系统默认为cmd, 其他系统默认为sh)
 	ShellDef2                        // 默认shell, 根据操作系统自动选择(Windows系统默认为powershell, 其他系统默认为sh)
+	ShellZsh                         // zsh shell
+	ShellFish                        // fish shell
+	ShellDash                        // dash shell
+	ShellKsh                         // ksh shell (Korn shell)
+	ShellAsh                         // ash shell (通过 busybox ash 执行)
+	ShellAuto                        // 自动选择第一个可用的shell ($SHELL、bash、sh, Windows上为pwsh、powershell、cmd)
+
+	shellCustomStart ShellType = 100 // 通过 RegisterShell 注册的自定义shell从该值开始分配
 )
 
 // String 返回shell类型的字符串表示
+//
+// 注意:
+//   - 已注册的shell返回注册名称, ShellDef1/ShellDef2/ShellAuto 返回实际使用的shell
+//   - 未注册的shell返回 "unknown"
 func (s ShellType) String() string {
-	switch s {
-	case ShellSh:
-		return "sh"
-
-	case ShellBash:
-		return "bash"
-
-	case ShellPwsh:
-		return "pwsh"
-
-	case ShellPowerShell:
-		return "powershell"
-
-	case ShellCmd:
-		return "cmd"
-
-	case ShellNone:
+	if s == ShellNone {
 		return "none"
+	}
+	s = s.resolve()
 
-	case ShellDef1:
-		if runtime.GOOS == "windows" {
-			return "cmd"
-		}
-		return "sh"
-
-	case ShellDef2:
-		if runtime.GOOS == "windows" {
-			return "powershell"
-		}
-		return "sh"
+	shellRegistry.mu.RLock()
+	defer shellRegistry.mu.RUnlock()
 
-	default:
-		return "unknown"
+	if spec, ok := shellRegistry.specs[s]; ok {
+		return spec.Name
 	}
+	return "unknown"
 }
 
-// shellFlags 用于返回shell类型的标志
-func (s ShellType) shellFlags() string {
-	switch s {
-	case ShellSh:
-		return "-c"
-
-	case ShellBash:
-		return "-c"
-
-	case ShellPwsh:
-		return "-Command"
-
-	case ShellPowerShell:
-		return "-Command"
-
-	case ShellCmd:
-		return "/c"
-
-	case ShellNone:
-		return ""
-
-	case ShellDef1:
-		if runtime.GOOS == "windows" {
-			return "/c"
-		}
-		return "-c"
+// Result 命令执行结果
+type Result struct {
+	Cmd      string        // 命令字符串
+	Stdout   []byte        // 捕获的标准输出
+	Stderr   []byte        // 捕获的标准错误
+	ExitCode int           // 退出码(0表示成功，-1表示无法提取的执行错误或命令未执行)
+	Err      error         // 执行错误, 成功时为nil
+	Start    time.Time     // 开始时间, 命令未执行时为零值
+	Duration time.Duration // 执行耗时
+}
 
-	case ShellDef2:
-		if runtime.GOOS == "windows" {
-			return "-Command"
-		}
-		return "-c"
+// Success 判断命令是否执行成功
+//
+// 返回:
+//   - bool: 执行错误为nil时返回true
+func (r *Result) Success() bool {
+	return r.Err == nil
+}
 
-	default:
-		return ""
-	}
+// KillStrategy 定义命令因上下文取消或超时被终止时的策略
+//
+// 注意:
+//   - 零值表示直接Kill命令进程, 与未设置时的行为一致
+//   - Group 仅在Unix系统上生效, 命令会以新的进程组启动, 信号发送给整个进程组
+//   - Tree 仅在Linux上遍历 /proc 作用于整个进程树, 能覆盖调用setsid脱离进程组的子进程; 其他Unix系统回退为Group
+type KillStrategy struct {
+	Signal      os.Signal     // 首先发送的信号, nil表示直接Kill
+	GracePeriod time.Duration // 发送Signal后等待进程退出的时间, 超时后强制Kill
+	Group       bool          // 是否作用于整个进程组, 避免shell派生的子进程残留
+	Tree        bool          // 是否作用于整个进程树, 参见 KillTree
 }
 
 // windowsExts 定义 Windows 可执行文件扩展名集合